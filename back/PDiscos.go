@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,19 +20,71 @@ type MBR struct {
 	MbrDskSignature  int32         // Número random que identifica de forma única a cada disco
 	DskFit           byte          // Tipo de ajuste de la partición: 'B', 'F', o 'W'
 	Partitions       [4]Partition1 // Arreglo con información de las 4 particiones
+	MbrAlineacion    int64         // Alineación en bytes del inicio y tamaño de las particiones
+}
+
+// Tamaño del MBR de los discos creados antes de MbrAlineacion. En esos discos la
+// primera partición puede empezar justo donde ahora está ese campo.
+var tamanoMBRLegado = int64(binary.Size(MBR{}) - binary.Size(MBR{}.MbrAlineacion))
+
+// Indica si el MBR es de un disco anterior a MbrAlineacion: alguna partición empieza
+// dentro de los bytes del campo. En esos discos el campo no se lee ni se escribe
+// para no tocar los datos de la partición, y la alineación es de 1 byte.
+func mbrLegado(mbr *MBR) bool {
+	for _, part := range mbr.Partitions {
+		if part.PartStatus != 0 && part.PartStart < int64(binary.Size(MBR{})) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes que ocupa el MBR al inicio del disco
+func tamanoMBR(mbr *MBR) int64 {
+	if mbrLegado(mbr) {
+		return tamanoMBRLegado
+	}
+	return int64(binary.Size(MBR{}))
+}
+
+// Lee el MBR del inicio del disco
+func leerMBR(file *os.File) (MBR, error) {
+	var mbr MBR
+	data := make([]byte, binary.Size(mbr))
+	if n, err := file.ReadAt(data, 0); int64(n) < tamanoMBRLegado {
+		return MBR{}, err
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &mbr); err != nil {
+		return MBR{}, err
+	}
+	if mbrLegado(&mbr) {
+		mbr.MbrAlineacion = 0
+	}
+	return mbr, nil
+}
+
+// Escribe el MBR al inicio del disco
+func escribirMBR(file *os.File, mbr *MBR) error {
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, mbr); err != nil {
+		return err
+	}
+	_, err := file.WriteAt(buffer.Bytes()[:tamanoMBR(mbr)], 0)
+	return err
 }
 
 type Disk struct {
 	Size       int64       `json:"size"`
 	Unit       string      `json:"unit"`
 	Fit        string      `json:"fit"`
+	Align      int64       `json:"align"`
 	Path       string      `json:"path"`
 	Partitions []Partition `json:"particiones"`
 }
 
 // -------------------------------------MKDIR-DISCOS--------------------------------
 
-func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, err error) {
+func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, align int64, err error) {
 	parts := strings.Fields(strings.ToLower(command2))
 	cleanedCommand := strings.SplitN(command2, "#", 2)[0]
 	cleanedCommand = strings.TrimSpace(cleanedCommand)
@@ -39,6 +93,7 @@ func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, err
 	// Valores por defecto
 	fit = "ff" // Valor por defecto es First Fit
 	unit = "m" // Valor por defecto es MB
+	align = 1  // Valor por defecto es sin alineación
 
 	// Mapa para validar los parámetros permitidos
 	validParams := map[string]bool{
@@ -46,6 +101,7 @@ func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, err
 		"-unit":  true,
 		"-path":  true,
 		"-fit":   true,
+		"-align": true,
 		"mkdisk": true,
 	}
 
@@ -76,6 +132,11 @@ func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, err
 		} else if strings.HasPrefix(part, "-fit=") {
 			fit = strings.TrimPrefix(part, "-fit=")
 			//foundFit = true
+		} else if strings.HasPrefix(part, "-align=") {
+			align, err = parseAlineacion(strings.TrimPrefix(part, "-align="))
+			if err != nil {
+				return
+			}
 		} else if strings.HasPrefix(part, "mkdisk") {
 			foundMkdisk = true
 		} else {
@@ -122,7 +183,7 @@ func parseMkdirCommand(command2 string) (size int64, unit, path, fit string, err
 }
 
 func processMkdirCommand(command2 string) (Disk, error) { // Cambiado para devolver fit y error
-	size, unit, path, fit, err := parseMkdirCommand(command2)
+	size, unit, path, fit, align, err := parseMkdirCommand(command2)
 	//fmt.Print("\n" + path + " " + fit + " " + unit + " " + string(size) + "54")
 	if err != nil {
 		return Disk{}, err
@@ -148,18 +209,119 @@ func processMkdirCommand(command2 string) (Disk, error) { // Cambiado para devol
 		fitByte = 'f'
 	}
 
-	crearDisco(path, size, fitByte)
+	crearDisco(path, size, fitByte, align)
 
 	return Disk{
-		Size: size,
-		Unit: unit,
-		Fit:  string(fitByte),
-		Path: path,
+		Size:  size,
+		Unit:  unit,
+		Fit:   string(fitByte),
+		Align: align,
+		Path:  path,
 	}, nil
 }
 
+// Alineación máxima que acepta mkdisk y que se lee de un MBR
+const alineacionMaxima = 64 * 1024 * 1024
+
+// Número y sufijo opcional de unidad de -align
+var patronAlineacion = regexp.MustCompile(`^(\d+)(b|k|kb|m|mb)?$`)
+
+// Convierte un valor de alineación como 512b, 4k, 4kb o 1m a bytes. La alineación
+// debe ser una potencia de dos de hasta 64m.
+func parseAlineacion(valor string) (int64, error) {
+	valor = strings.ToLower(strings.TrimSpace(valor))
+	partes := patronAlineacion.FindStringSubmatch(valor)
+	if partes == nil {
+		return 0, fmt.Errorf("valor de alineación inválido: %s", valor)
+	}
+	multiplicador := int64(1)
+	switch partes[2] {
+	case "k", "kb":
+		multiplicador = 1024
+	case "m", "mb":
+		multiplicador = 1024 * 1024
+	}
+	numero, err := strconv.ParseInt(partes[1], 10, 64)
+	if err != nil || numero <= 0 || numero > alineacionMaxima/multiplicador {
+		return 0, fmt.Errorf("valor de alineación inválido: %s", valor)
+	}
+	alineacion := numero * multiplicador
+	if !alineacionValida(alineacion) {
+		return 0, fmt.Errorf("la alineación debe ser una potencia de dos: %s", valor)
+	}
+	return alineacion, nil
+}
+
+// Indica si la alineación es una potencia de dos entre 1 y alineacionMaxima
+func alineacionValida(alineacion int64) bool {
+	return alineacion > 0 && alineacion <= alineacionMaxima && alineacion&(alineacion-1) == 0
+}
+
+// Redondea valor hacia arriba al siguiente múltiplo de la alineación
+func alinear(valor, alineacion int64) int64 {
+	if alineacion <= 1 {
+		return valor
+	}
+	return (valor + alineacion - 1) / alineacion * alineacion
+}
+
+// Alineación configurada en el MBR. Los discos sin alineación, o con un valor que
+// mkdisk no habría aceptado (por ejemplo restos de datos después de la tabla en un
+// disco anterior a MbrAlineacion), se tratan como de 1 byte.
+func alineacionMBR(mbr MBR) int64 {
+	if !alineacionValida(mbr.MbrAlineacion) {
+		return 1
+	}
+	return mbr.MbrAlineacion
+}
+
+// Calcula los bytes que se pierden por la alineación: los huecos menores a la
+// alineación que quedan entre el MBR, las particiones y los EBRs de las lógicas.
+func desperdicioAlineacion(mbr MBR, ebrs []EBR) int64 {
+	alineacion := alineacionMBR(mbr)
+	if alineacion == 1 {
+		return 0
+	}
+
+	var desperdicio int64
+	contarHuecos := func(inicio int64, rangos [][2]int64) {
+		sort.Slice(rangos, func(i, j int) bool { return rangos[i][0] < rangos[j][0] })
+		fin := inicio
+		for _, r := range rangos {
+			if hueco := r[0] - fin; hueco > 0 && hueco < alineacion {
+				desperdicio += hueco
+			}
+			if r[1] > fin {
+				fin = r[1]
+			}
+		}
+	}
+
+	var particiones [][2]int64
+	var extendida *Partition1
+	for i, part := range mbr.Partitions {
+		if part.PartStatus == 0 {
+			continue
+		}
+		particiones = append(particiones, [2]int64{part.PartStart, part.PartStart + part.PartS})
+		if part.PartType == 'e' {
+			extendida = &mbr.Partitions[i]
+		}
+	}
+	contarHuecos(tamanoMBR(&mbr), particiones)
+
+	if extendida != nil {
+		var logicas [][2]int64
+		for _, ebr := range ebrs {
+			logicas = append(logicas, [2]int64{ebr.Start, ebr.Start + ebr.Size})
+		}
+		contarHuecos(extendida.PartStart, logicas)
+	}
+	return desperdicio
+}
+
 // Función para crear un nuevo disco con un MBR
-func crearDisco(path string, size int64, fit byte) {
+func crearDisco(path string, size int64, fit byte, align int64) {
 	var messages []string
 	// Crear los directorios necesarios para la ruta si no existen
 	dir := filepath.Dir(path)
@@ -170,13 +332,13 @@ func crearDisco(path string, size int64, fit byte) {
 	}
 
 	// Crear archivo binario para el disco
-	file, err := os.Create(path)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		messages = append(messages, fmt.Sprintf("Error al crear el archivo del disco: %s", err))
 		fmt.Println("Error al crear el archivo del disco:", err)
 		return
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Llenar el archivo con ceros para simular el espacio del disco
 	if err := file.Truncate(size); err != nil {
@@ -198,6 +360,7 @@ func crearDisco(path string, size int64, fit byte) {
 		MbrFechaCreacion: fechaCreacionBytes,
 		MbrDskSignature:  int32(time.Now().UnixNano()), // Generar un número random
 		DskFit:           fit,
+		MbrAlineacion:    align,
 	}
 
 	// Escribir el MBR en el inicio del archivo
@@ -266,7 +429,9 @@ func deleteDisk(path string) error {
 		return fmt.Errorf("operación cancelada por el usuario")
 	}
 
-	// Intentar eliminar el archivo
+	// Intentar eliminar el archivo, esperando a que terminen los comandos sobre el disco
+	liberar := motor.bloquearDisco(path, true)
+	defer liberar()
 	if err := os.Remove(path); err != nil {
 		return fmt.Errorf("error al eliminar el archivo: %v", err)
 	}
@@ -275,42 +440,45 @@ func deleteDisk(path string) error {
 	return nil
 }
 
+// Comando rmdisk: elimina el archivo del disco y lo quita de la lista de discos.
+// Un disco creado en otra ejecución no está en la lista y también se elimina
+func eliminarDisco(path string) error {
+	if err := deleteDisk(path); err != nil {
+		return err
+	}
+	motor.quitarDisco(path)
+	return nil
+}
+
 // Lee el MBR presente en el disco
 func readMBR(diskPath string) (MBR, error) {
-	file, err := os.Open(diskPath)
+	file, cerrar, err := motor.abrirDisco(diskPath, os.O_RDONLY, 0)
 	if err != nil {
 		return MBR{}, fmt.Errorf("error al abrir el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
-	var mbr MBR
-	mbrData := make([]byte, binary.Size(mbr))
-	_, err = file.ReadAt(mbrData, 0)
+	mbr, err := leerMBR(file)
 	if err != nil {
 		return MBR{}, fmt.Errorf("error al leer el MBR del disco: %v", err)
 	}
 
-	buffer := bytes.NewBuffer(mbrData)
-	if err := binary.Read(buffer, binary.LittleEndian, &mbr); err != nil {
-		return MBR{}, fmt.Errorf("error al decodificar el MBR: %v", err)
-	}
-
 	return mbr, nil
 }
 
 // Leer e imprimir la información del MBR en el disco
 func PrintMBR(path string) {
 	// Abrir el archivo del disco
-	file, err := os.Open(path)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDONLY, 0)
 	if err != nil {
 		fmt.Println("Error al abrir el archivo del disco:", err)
 		return
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		fmt.Println("Error al leer el MBR:", err)
 		return
 	}
@@ -321,5 +489,6 @@ func PrintMBR(path string) {
 	fmt.Printf("Fecha de creación: %s\n", string(mbr.MbrFechaCreacion[:]))
 	fmt.Printf("Firma del disco: %d\n", mbr.MbrDskSignature)
 	fmt.Printf("Ajuste de partición: %c\n", mbr.DskFit)
+	fmt.Printf("Alineación: %d bytes\n", alineacionMBR(mbr))
 	fmt.Println("------------------------------------------------")
 }
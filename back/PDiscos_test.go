@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Formato del MBR de los discos creados antes de MbrAlineacion
+type mbrAnterior struct {
+	MbrTamano        int64
+	MbrFechaCreacion [19]byte
+	MbrDskSignature  int32
+	DskFit           byte
+	Partitions       [4]Partition1
+}
+
+func TestMBRAlineacionDespuesDeLaTabla(t *testing.T) {
+	if int(tamanoMBRLegado) != binary.Size(mbrAnterior{}) {
+		t.Fatalf("tamanoMBRLegado = %d, el MBR anterior mide %d", tamanoMBRLegado, binary.Size(mbrAnterior{}))
+	}
+	var mbr MBR
+	mbr.Partitions[0].PartStart = 0x0102030405060708
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, &mbr); err != nil {
+		t.Fatal(err)
+	}
+	var anterior mbrAnterior
+	if err := binary.Read(bytes.NewReader(buffer.Bytes()), binary.LittleEndian, &anterior); err != nil {
+		t.Fatal(err)
+	}
+	if anterior.Partitions != mbr.Partitions {
+		t.Fatal("la tabla de particiones cambió de posición dentro del MBR")
+	}
+}
+
+// Un disco con el MBR anterior se lee con su tabla de particiones y sin alineación,
+// y al escribir el MBR no se tocan los primeros bytes de la primera partición
+func TestMBRDeDiscoAnterior(t *testing.T) {
+	const tamano = 64 * 1024
+	path := filepath.Join(t.TempDir(), "anterior.mia")
+	anterior := mbrAnterior{MbrTamano: tamano, MbrDskSignature: 42, DskFit: 'F'}
+	anterior.Partitions[0] = Partition1{PartStatus: '0', PartType: 'p', PartFit: 'F', PartStart: tamanoMBRLegado, PartS: 8 * 1024}
+	copy(anterior.Partitions[0].PartName[:], "p1")
+
+	disco := make([]byte, tamano)
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, &anterior); err != nil {
+		t.Fatal(err)
+	}
+	copy(disco, buffer.Bytes())
+	datos := []byte{0xEF, 0x53, 1, 2, 3, 4, 5, 6}
+	copy(disco[tamanoMBRLegado:], datos)
+	if err := os.WriteFile(path, disco, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mbr, err := readMBR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mbr.Partitions != anterior.Partitions || mbr.MbrTamano != tamano || mbr.DskFit != 'F' {
+		t.Fatalf("MBR anterior mal leído: %+v", mbr)
+	}
+	if mbr.MbrAlineacion != 0 || alineacionMBR(mbr) != 1 {
+		t.Errorf("alineación de un disco anterior = %d", mbr.MbrAlineacion)
+	}
+
+	if err := crearParticion(path, 4*1024, "p2", "p", ""); err != nil {
+		t.Fatal(err)
+	}
+	contenido, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contenido[tamanoMBRLegado:tamanoMBRLegado+int64(len(datos))], datos) {
+		t.Fatal("escribir el MBR modificó el inicio de la primera partición")
+	}
+	if mbr, err = readMBR(path); err != nil {
+		t.Fatal(err)
+	}
+	if mbr.Partitions[0] != anterior.Partitions[0] {
+		t.Errorf("la primera partición cambió: %+v", mbr.Partitions[0])
+	}
+	if p2 := mbr.Partitions[1]; p2.PartStart != tamanoMBRLegado+8*1024 || p2.PartS != 4*1024 {
+		t.Errorf("segunda partición en %d con %d bytes", p2.PartStart, p2.PartS)
+	}
+}
+
+func TestMBRDeDiscoNuevoConAlineacion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nuevo.mia")
+	crearDisco(path, 64*1024, 'F', 4096)
+	mbr, err := readMBR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mbr.MbrAlineacion != 4096 || mbrLegado(&mbr) {
+		t.Fatalf("alineación del disco nuevo = %d, legado = %t", mbr.MbrAlineacion, mbrLegado(&mbr))
+	}
+	if err := crearParticion(path, 5000, "p1", "p", ""); err != nil {
+		t.Fatal(err)
+	}
+	if mbr, err = readMBR(path); err != nil {
+		t.Fatal(err)
+	}
+	if p1 := mbr.Partitions[0]; p1.PartStart != 4096 || p1.PartS != 8192 {
+		t.Errorf("partición alineada en %d con %d bytes", p1.PartStart, p1.PartS)
+	}
+	if mbr.MbrAlineacion != 4096 {
+		t.Errorf("la alineación se perdió al escribir el MBR: %d", mbr.MbrAlineacion)
+	}
+}
+
+func TestParseAlineacion(t *testing.T) {
+	validos := map[string]int64{"512b": 512, "512": 512, "4k": 4096, "4kb": 4096, "1m": 1 << 20, "1MB": 1 << 20, "64m": 64 << 20}
+	for valor, esperado := range validos {
+		if alineacion, err := parseAlineacion(valor); err != nil || alineacion != esperado {
+			t.Errorf("parseAlineacion(%q) = %d, %v; se esperaba %d", valor, alineacion, err, esperado)
+		}
+	}
+	for _, valor := range []string{"4mk", "4bk", "4kbm", "4bb", "k", "0k", "3k", "-4k", "128m", "4 k", "4g"} {
+		if alineacion, err := parseAlineacion(valor); err == nil {
+			t.Errorf("parseAlineacion(%q) = %d, se esperaba un error", valor, alineacion)
+		}
+	}
+}
+
+// Una alineación guardada que mkdisk no aceptaría se ignora
+func TestAlineacionMBRInvalida(t *testing.T) {
+	for _, valor := range []int64{0, 1, -4096, 3000, alineacionMaxima * 2, 0x0102030405060708} {
+		if alineacion := alineacionMBR(MBR{MbrAlineacion: valor}); alineacion != 1 {
+			t.Errorf("alineacionMBR con %d = %d", valor, alineacion)
+		}
+	}
+	if alineacion := alineacionMBR(MBR{MbrAlineacion: 4096}); alineacion != 4096 {
+		t.Errorf("alineacionMBR con 4096 = %d", alineacion)
+	}
+}
+
+// Responde la confirmación de deleteDisk desde la entrada estándar
+func confirmarPrueba(t *testing.T, respuesta string) {
+	t.Helper()
+	lector, escritor, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := escritor.WriteString(respuesta + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	escritor.Close()
+	anterior := os.Stdin
+	os.Stdin = lector
+	t.Cleanup(func() {
+		os.Stdin = anterior
+		lector.Close()
+	})
+}
+
+// rmdisk elimina discos que no están en la lista del motor, como los creados en
+// otra ejecución, y quita de la lista los que sí están
+func TestEliminarDisco(t *testing.T) {
+	dir := t.TempDir()
+	externo := filepath.Join(dir, "externo.mia")
+	crearDisco(externo, 64*1024, 'F', 1)
+	confirmarPrueba(t, "s")
+	if err := eliminarDisco(externo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(externo); !os.IsNotExist(err) {
+		t.Fatalf("el disco sigue existiendo: %v", err)
+	}
+
+	registrado := filepath.Join(dir, "registrado.mia")
+	crearDisco(registrado, 64*1024, 'F', 1)
+	motor.agregarDisco(Disk{Path: registrado})
+	t.Cleanup(func() { motor.quitarDisco(registrado) })
+	confirmarPrueba(t, "s")
+	if err := eliminarDisco(registrado); err != nil {
+		t.Fatal(err)
+	}
+	for _, disco := range motor.listarDiscos() {
+		if disco.Path == registrado {
+			t.Error("el disco eliminado sigue en la lista")
+		}
+	}
+
+	// Sin confirmación o sin archivo no se elimina nada
+	conservado := filepath.Join(dir, "conservado.mia")
+	crearDisco(conservado, 64*1024, 'F', 1)
+	confirmarPrueba(t, "n")
+	if err := eliminarDisco(conservado); err == nil {
+		t.Error("se eliminó un disco sin confirmación")
+	}
+	if _, err := os.Stat(conservado); err != nil {
+		t.Error("el disco cancelado ya no existe")
+	}
+	if err := eliminarDisco(externo); err == nil {
+		t.Error("se eliminó un disco inexistente")
+	}
+}
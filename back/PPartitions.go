@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/binary"
 	"fmt"
 	"os"
 	"regexp"
@@ -26,6 +25,18 @@ type Partition struct {
 	Fit  string `json:"fit"`
 }
 
+// Errores de validación al crear particiones
+var (
+	ErrLimitePrimarias    = nuevoErrorCodigo("PART_LIMITE_PRIMARIAS", "no se pueden crear más de 4 particiones primarias")
+	ErrTablaLlena         = nuevoErrorCodigo("PART_TABLA_LLENA", "la tabla de particiones del MBR está llena (4 entradas entre primarias y extendida)")
+	ErrExtendidaExistente = nuevoErrorCodigo("PART_EXTENDIDA_EXISTENTE", "ya existe una partición extendida en el disco")
+	ErrSinExtendida       = nuevoErrorCodigo("PART_SIN_EXTENDIDA", "no existe una partición extendida en el disco")
+	ErrNombreDuplicado    = nuevoErrorCodigo("PART_NOMBRE_DUPLICADO", "ya existe una partición con ese nombre en el disco")
+	ErrTipoParticion      = nuevoErrorCodigo("PART_TIPO_INVALIDO", "tipo de partición no válido, debe ser P, E o L")
+	ErrSinEspacio         = nuevoErrorCodigo("PART_SIN_ESPACIO", "no hay suficiente espacio en el disco para crear la partición")
+	ErrTraslape           = nuevoErrorCodigo("PART_TRASLAPE", "la partición se traslapa con otra")
+)
+
 // -------------------------------- FDISK-DISCOS--------------------------------
 func parseFDISKCommand(command2 string) (size int, unit, path, partitionType, fit, deleteOption string, name, add string, err error) {
 	//var delete string
@@ -123,25 +134,40 @@ func parseFDISKCommand(command2 string) (size int, unit, path, partitionType, fi
 	return size, unit, path, partitionType, fit, deleteOption, name, add, nil
 }
 
-func crearParticion(path string, size int64, name string, particionType string) error {
+// Crea una partición primaria, extendida o lógica con el ajuste fit (bf, ff o wf).
+// Sin fit las primarias y la extendida usan el ajuste del disco.
+func crearParticion(path string, size int64, name string, particionType string, fit string) error {
+	// Las particiones lógicas viven dentro de la extendida, no en la tabla del MBR
+	if particionType == "l" {
+		if fit == "" {
+			fit = "wf"
+		}
+		return crearParticionLogica(path, size, name, fit)
+	}
+
 	// Abrir el archivo del disco
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR, 0644)
 	if err != nil {
 		err = fmt.Errorf("Error al abrir el archivo del disco: %v", err)
 		return err
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		err = fmt.Errorf("Error al leer el MBR: %v", err)
 		return err
 	}
 
+	// Validar los límites de primarias y extendida según el contenido actual del MBR
+	if err := validarNuevaParticion(file, &mbr, particionType[0], name); err != nil {
+		return err
+	}
+
 	// Buscar la primera posición libre en el array de particiones
 	found := false
-	var startPosition int64 = int64(binary.Size(mbr))
+	var startPosition int64 = tamanoMBR(&mbr)
 	for i := 0; i < len(mbr.Partitions); i++ {
 		if mbr.Partitions[i].PartStatus == 0 {
 			for j := 0; j < i; j++ {
@@ -151,18 +177,35 @@ func crearParticion(path string, size int64, name string, particionType string)
 				}
 			}
 
+			// Alinear el inicio y el tamaño según la alineación del disco
+			startPosition = alinear(startPosition, alineacionMBR(mbr))
+			size = alinear(size, alineacionMBR(mbr))
+
 			// Verificar que hay espacio suficiente para la nueva partición
 			if startPosition+size > mbr.MbrTamano {
-				err = fmt.Errorf("No hay suficiente espacio en el disco para crear la partición.")
 				fmt.Println("Error: No hay suficiente espacio en el disco para crear la partición.")
-				return err
+				return fmt.Errorf("%w: '%s' necesita %d bytes desde el byte %d", ErrSinEspacio, name, size, startPosition)
+			}
+			// El espacio después de la partición anterior puede estar ocupado por una
+			// partición de una entrada posterior de la tabla
+			for j, otra := range mbr.Partitions {
+				if j != i && otra.PartStatus != 0 && startPosition < otra.PartStart+otra.PartS && otra.PartStart < startPosition+size {
+					return fmt.Errorf("%w: '%s' [%d, %d) con '%s'", ErrTraslape, name, startPosition, startPosition+size,
+						strings.Trim(string(otra.PartName[:]), "\x00"))
+				}
+			}
+
+			// El ajuste indicado o, si no hay, el del MBR
+			ajuste := mbr.DskFit
+			if fit != "" {
+				ajuste = strings.ToUpper(fit)[0]
 			}
 
 			// Crear la partición en la posición libre
 			mbr.Partitions[i] = Partition1{
 				PartStatus: '0',              // Activar la partición
 				PartType:   particionType[0], // Suponiendo que es una partición primaria
-				PartFit:    mbr.DskFit,       // Utilizar el fit del MBR
+				PartFit:    ajuste,
 				PartStart:  startPosition,
 				PartS:      size,
 			}
@@ -180,52 +223,98 @@ func crearParticion(path string, size int64, name string, particionType string)
 		return err
 	}
 
-	// Mover el cursor al inicio del archivo para actualizar el MBR
-	if _, err := file.Seek(0, 0); err != nil {
-		err = fmt.Errorf("Error al posicionarse al inicio del archivo: %v", err)
-		fmt.Println("Error al posicionarse al inicio del archivo:", err)
-		return err
-	}
-
 	// Escribir el MBR actualizado en el archivo
-	if err := binary.Write(file, binary.LittleEndian, &mbr); err != nil {
+	if err := escribirMBR(file, &mbr); err != nil {
 		err = fmt.Errorf("Error al escribir el MBR actualizado: %v", err)
 		fmt.Println("Error al escribir el MBR actualizado:", err)
 		return err
 	}
 
 	// Ahora que la partición ha sido creada en el archivo, también la agregamos a la estructura de discos en memoria
-	for i := range disks {
-		if disks[i].Path == path {
-			// Crear la partición y agregarla al disco en la estructura en memoria
-			newPartition := Partition{
-				Name: name,
-				Size: size,
-				Type: particionType,
-			}
+	newPartition := Partition{
+		Name: name,
+		Size: size,
+		Type: particionType,
+	}
+	if motor.agregarParticionDisco(path, newPartition) {
+		fmt.Println("Partición creada exitosamente y agregada a la estructura en memoria.")
+	}
 
-			// Agregar la partición al disco en memoria
-			disks[i].Partitions = append(disks[i].Partitions, newPartition)
+	return nil
+}
 
-			fmt.Println("Partición creada exitosamente y agregada a la estructura en memoria.")
-			break
+// Valida contra el MBR del disco (y su cadena de EBRs) que se pueda crear una
+// partición del tipo indicado: como máximo 4 entradas entre primarias y extendida,
+// una sola extendida, lógicas solo dentro de una extendida y nombres únicos.
+func validarNuevaParticion(file *os.File, mbr *MBR, tipo byte, nombre string) error {
+	primarias, libres := 0, 0
+	var extendida *Partition1
+	for i := range mbr.Partitions {
+		part := &mbr.Partitions[i]
+		if part.PartStatus == 0 {
+			libres++
+			continue
+		}
+		if strings.Trim(string(part.PartName[:]), "\x00") == nombre {
+			return fmt.Errorf("%w: '%s'", ErrNombreDuplicado, nombre)
+		}
+		switch part.PartType {
+		case 'p', 'P':
+			primarias++
+		case 'e', 'E':
+			extendida = part
+		}
+	}
+
+	// Los nombres de las lógicas también deben ser únicos
+	if extendida != nil {
+		ebrs, err := leerCadenaEBR(file, *extendida)
+		if err != nil {
+			return err
+		}
+		for _, ebr := range ebrs {
+			if strings.Trim(string(ebr.Name[:]), "\x00") == nombre {
+				return fmt.Errorf("%w: '%s'", ErrNombreDuplicado, nombre)
+			}
 		}
 	}
 
+	switch tipo {
+	case 'p', 'P':
+		if primarias >= len(mbr.Partitions) {
+			return ErrLimitePrimarias
+		}
+		if libres == 0 {
+			return ErrTablaLlena
+		}
+	case 'e', 'E':
+		if extendida != nil {
+			return ErrExtendidaExistente
+		}
+		if libres == 0 {
+			return ErrTablaLlena
+		}
+	case 'l', 'L':
+		if extendida == nil {
+			return ErrSinExtendida
+		}
+	default:
+		return ErrTipoParticion
+	}
 	return nil
 }
 
 func eliminarParticion(path, name, deleteType string) error {
 	// Abrir el archivo del disco
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("Error al abrir el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		return fmt.Errorf("Error al leer el MBR: %v", err)
 	}
 
@@ -279,11 +368,7 @@ func eliminarParticion(path, name, deleteType string) error {
 		}
 	}
 
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("Error al posicionarse al inicio del archivo: %v", err)
-	}
-
-	if err := binary.Write(file, binary.LittleEndian, &mbr); err != nil {
+	if err := escribirMBR(file, &mbr); err != nil {
 		return fmt.Errorf("Error al escribir el MBR actualizado: %v", err)
 	}
 
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCrearParticionRechazos(t *testing.T) {
+	casos := []struct {
+		nombre string
+		crear  func(path string) error
+		err    error
+	}{
+		{"traslape", func(path string) error {
+			// El hueco que deja p2 (como fdisk -delete=fast) es menor que la partición
+			// nueva, que alcanzaría a p3
+			file, err := os.OpenFile(path, os.O_RDWR, 0644)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			mbr, err := leerMBR(file)
+			if err != nil {
+				return err
+			}
+			mbr.Partitions[1].PartStatus = 0
+			if err := escribirMBR(file, &mbr); err != nil {
+				return err
+			}
+			return crearParticion(path, 16*1024, "p4", "p", "")
+		}, ErrTraslape},
+		{"muy grande", func(path string) error {
+			return crearParticion(path, 1024*1024, "p4", "p", "")
+		}, ErrSinEspacio},
+		{"nombre duplicado", func(path string) error {
+			return crearParticion(path, 4*1024, "p1", "e", "")
+		}, ErrNombreDuplicado},
+		{"segunda extendida", func(path string) error {
+			if err := crearParticion(path, 16*1024, "ext", "e", ""); err != nil {
+				return err
+			}
+			return crearParticion(path, 8*1024, "ext2", "e", "")
+		}, ErrExtendidaExistente},
+		{"lógica sin extendida", func(path string) error {
+			return crearParticion(path, 4*1024, "l1", "l", "")
+		}, ErrSinExtendida},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "disco.mia")
+			crearDisco(path, 64*1024, 'F', 4096)
+			for _, nombre := range []string{"p1", "p2", "p3"} {
+				if err := crearParticion(path, 8*1024, nombre, "p", ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := caso.crear(path); !errors.Is(err, caso.err) {
+				t.Fatalf("error = %v, se esperaba %v", err, caso.err)
+			}
+		})
+	}
+}
+
+// El ajuste de fdisk llega a la partición primaria y a la lógica
+func TestCrearParticionConAjuste(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	crearDisco(path, 64*1024, 'F', 4096)
+	if err := crearParticion(path, 8*1024, "p1", "p", "bf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearParticion(path, 16*1024, "ext", "e", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearParticion(path, 4*1024, "l1", "l", "bf"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mbr.Partitions[0].PartFit != 'B' || mbr.Partitions[1].PartFit != 'F' {
+		t.Errorf("ajustes de la tabla: %c y %c", mbr.Partitions[0].PartFit, mbr.Partitions[1].PartFit)
+	}
+	ebrs := leerEBRsDelMBR(file, mbr)
+	if len(ebrs) != 1 || ebrs[0].Fit != 'b' {
+		t.Errorf("ajuste de la lógica: %+v", ebrs)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -46,14 +45,16 @@ type actual_user struct {
 	Pid string
 }
 
+// Inodo tal como se guarda en la tabla de inodos. Todos los campos tienen tamaño
+// fijo; se escribe y se lee con codificarInodo y decodificarInodo (tamanoInodo bytes).
 type Inode struct {
-	UID    int       // i_uid: UID del usuario propietario del archivo o carpeta
-	GID    int       // i_gid: GID del grupo al que pertenece el archivo o carpeta
-	Size   int       // i_size: Tamaño del archivo en bytes
-	ATIME  time.Time // i_atime: Última fecha en que se leyó el inodo sin modificarlo
-	CTIME  time.Time // i_ctime: Fecha en la que se creó el inodo
-	MTIME  time.Time // i_mtime: Última fecha en la que se modifica el inodo
-	Blocks [16]int   // i_block: Array de bloques, 12 directos, 1 simple indirecto, 1 doble indirecto, 1 triple indirecto
+	UID    int32     // i_uid: UID del usuario propietario del archivo o carpeta
+	GID    int32     // i_gid: GID del grupo al que pertenece el archivo o carpeta
+	Size   int64     // i_size: Tamaño del archivo en bytes
+	ATIME  [19]byte  // i_atime: Última fecha en que se leyó el inodo sin modificarlo
+	CTIME  [19]byte  // i_ctime: Fecha en la que se creó el inodo
+	MTIME  [19]byte  // i_mtime: Última fecha en la que se modifica el inodo
+	Blocks [15]int32 // i_block: Array de bloques, 12 directos, 1 simple indirecto, 1 doble indirecto, 1 triple indirecto
 	Type   byte      // i_type: Tipo de archivo (1 = Archivo, 0 = Carpeta)
 	Perms  [3]byte   // i_perm: Permisos del archivo o carpeta (octal)
 }
@@ -72,6 +73,7 @@ const (
 
 type User struct {
 	ID       int
+	GID      int
 	Username string
 	Password string
 	Group    string
@@ -80,7 +82,7 @@ type User struct {
 // BlockContent representa el contenido dentro de un bloque de carpeta.
 type BlockContent struct {
 	Name  [12]byte
-	Inode int
+	Inode int32
 }
 
 // DirectoryBlock representa la estructura de un bloque de carpeta en un sistema de archivos EXT2.
@@ -97,6 +99,11 @@ type Fileblock struct {
 	B_content [64]byte
 }
 
+// BLOQUES DE APUNTADORES: usados por los apuntadores indirectos del inodo
+type PointerBlock struct {
+	B_pointers [16]int32
+}
+
 /*-----------------------------------Administración del Sistema de Archivos-----------------------------------*/
 /*----------------------------------------------MKFS----------------------------------------------*/
 //Analiza el comando MKFS y extrae los parámetros
@@ -112,10 +119,25 @@ func parseMkfsCommand(command string) (id, fsType string, full bool, err error)
 		if strings.HasPrefix(part, "-id=") {
 			id = strings.TrimPrefix(part, "-id=")
 		} else if strings.HasPrefix(part, "-type=") {
-			fsType = strings.TrimPrefix(part, "-type=")
-			if fsType == "full" {
+			switch strings.TrimPrefix(part, "-type=") {
+			case "full":
 				full = true
-				fsType = "ext2" // Mantener el formato predeterminado en caso de que no se especifique
+			case "fast":
+				full = false
+			default:
+				err = fmt.Errorf("tipo de formateo inválido: %s", strings.TrimPrefix(part, "-type="))
+				return
+			}
+		} else if strings.HasPrefix(part, "-fs=") {
+			// 2fs = EXT2, 3fs = EXT3
+			switch strings.TrimPrefix(part, "-fs=") {
+			case "2fs":
+				fsType = "ext2"
+			case "3fs":
+				fsType = "ext3"
+			default:
+				err = fmt.Errorf("sistema de archivos inválido: %s", strings.TrimPrefix(part, "-fs="))
+				return
 			}
 		}
 	}
@@ -126,20 +148,74 @@ func parseMkfsCommand(command string) (id, fsType string, full bool, err error)
 	return
 }
 
-// Crea el archivo users.txt dentro de la partición formateada
-func createUsersFile(file *os.File, blockStart int) error {
+// Crea la carpeta raíz (inodo 0) y el archivo /users.txt (inodo 1) dentro de la
+// partición formateada, marca sus inodos y bloques en los bitmaps y actualiza los
+// contadores del SuperBlock. El SuperBlock se escribe después en formatPartition.
+func createUsersFile(file *os.File, superblock *SuperBlock) error {
 	// Contenido inicial de users.txt
 	usersContent := "1,G,root\n1,U,root,root,123\n"
 
-	// Posicionarse en el inicio del bloque donde se escribirá users.txt
-	if _, err := file.Seek(int64(blockStart), 0); err != nil {
-		return fmt.Errorf("Error al posicionarse para escribir users.txt: %v", err)
+	// Bloques de users.txt, uno por cada 64 bytes de contenido
+	var bloquesUsuarios []Fileblock
+	for i := 0; i < len(usersContent); i += len(Fileblock{}.B_content) {
+		var bloque Fileblock
+		copy(bloque.B_content[:], usersContent[i:])
+		bloquesUsuarios = append(bloquesUsuarios, bloque)
+	}
+	if superblock.InodesCount < 2 || int32(1+len(bloquesUsuarios)) > superblock.BlocksCount {
+		return fmt.Errorf("la partición es demasiado pequeña para crear la carpeta raíz y users.txt")
+	}
+
+	// Carpeta raíz: su bloque 0 contiene ".", ".." y users.txt
+	raiz := nuevoInodo(1, 1, DirType, "775")
+	raiz.Blocks[0] = 0
+	carpeta := nuevoFolderBlock()
+	copy(carpeta.B_content[0].Name[:], ".")
+	carpeta.B_content[0].Inode = inodoRaiz
+	copy(carpeta.B_content[1].Name[:], "..")
+	carpeta.B_content[1].Inode = inodoRaiz
+	copy(carpeta.B_content[2].Name[:], "users.txt")
+	carpeta.B_content[2].Inode = inodoUsuarios
+
+	// Archivo users.txt: sus bloques siguen al de la raíz
+	usuarios := nuevoInodo(1, 1, FileType, "664")
+	usuarios.Size = int64(len(usersContent))
+	for i := range bloquesUsuarios {
+		usuarios.Blocks[i] = int32(1 + i)
+	}
+
+	if err := escribirInodo(file, superblock, inodoRaiz, &raiz); err != nil {
+		return err
+	}
+	if err := escribirInodo(file, superblock, inodoUsuarios, &usuarios); err != nil {
+		return err
+	}
+	if err := escribirBloque(file, superblock, 0, &carpeta); err != nil {
+		return err
+	}
+	for i := range bloquesUsuarios {
+		if err := escribirBloque(file, superblock, int32(1+i), &bloquesUsuarios[i]); err != nil {
+			return err
+		}
+	}
+
+	// Marcar los inodos y bloques usados
+	for _, inodo := range []int32{inodoRaiz, inodoUsuarios} {
+		if err := marcarBitmap(file, superblock.BmInodeStart, inodo, true); err != nil {
+			return err
+		}
 	}
-	// Escribir el contenido de users.txt
-	if _, err := file.Write([]byte(usersContent)); err != nil {
-		return fmt.Errorf("Error al escribir el archivo users.txt: %v", err)
+	bloquesUsados := int32(1 + len(bloquesUsuarios))
+	for bloque := int32(0); bloque < bloquesUsados; bloque++ {
+		if err := marcarBitmap(file, superblock.BmBlockStart, bloque, true); err != nil {
+			return err
+		}
 	}
 
+	superblock.FirstInode = 2
+	superblock.FirstBlock = bloquesUsados
+	superblock.FreeInodesCount = superblock.InodesCount - 2
+	superblock.FreeBlocksCount = superblock.BlocksCount - bloquesUsados
 	return nil
 }
 
@@ -167,37 +243,74 @@ func initializeBitmaps(file *os.File, superblock *SuperBlock) error {
 	return nil
 }
 
-// Formatea la partición y crea el archivo users.txt
-func formatPartition(id, fsType string, full bool) error {
+// Formatea la partición y crea el archivo users.txt. Con full se escriben ceros en
+// toda la partición antes de crear el sistema de archivos y se devuelve el avance
+// del borrado, un mensaje por cada 10%.
+func formatPartition(id, fsType string, full bool) (progreso []string, err error) {
 	id = strings.ToLower(id)
 
 	// Buscar la partición montada por ID
-	partition, exists := mountedPartitions[id]
+	partition, exists := motor.montaje(id)
 	if !exists {
-		return fmt.Errorf("Error al formatear la partición: partición con ID '%s' no está montada", id)
+		return progreso, fmt.Errorf("Error al formatear la partición: partición con ID '%s' no está montada", id)
+	}
+
+	// No se puede formatear una partición montada en modo solo lectura
+	if err := verificarEscritura(id); err != nil {
+		return progreso, err
 	}
 
-	// Validar si la partición es primaria
-	if partition.Partition.PartType != 'p' {
-		return fmt.Errorf("Error al formatear la partición: la partición con ID '%s' no es primaria", id)
+	// Validar si la partición es primaria o lógica
+	if partition.Partition.PartType != 'p' && partition.Partition.PartType != 'l' {
+		return progreso, fmt.Errorf("Error al formatear la partición: la partición con ID '%s' no es primaria ni lógica", id)
 	}
 
 	// Abrir el archivo del disco
-	file, err := os.OpenFile(partition.Path, os.O_RDWR, 0666)
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDWR, 0666)
 	if err != nil {
-		return fmt.Errorf("Error al abrir el disco: %v", err)
+		return progreso, fmt.Errorf("Error al abrir el disco: %v", err)
+	}
+	defer cerrar()
+
+	// El formateo completo limpia toda la partición antes de crear las estructuras;
+	// el rápido solo reconstruye los metadatos
+	if full {
+		ultimo := int64(-1)
+		err := escribirCeros(file, partition.Partition.PartStart, partition.Partition.PartS, func(escrito, total int64) {
+			porcentaje := escrito * 100 / total
+			if porcentaje/10 != ultimo/10 {
+				ultimo = porcentaje
+				mensaje := fmt.Sprintf("Formateo completo de '%s': %d%%", id, porcentaje)
+				fmt.Println(mensaje)
+				progreso = append(progreso, mensaje)
+			}
+		})
+		if err != nil {
+			return progreso, err
+		}
 	}
-	defer file.Close()
 
 	// Calcular los tamaños de las estructuras
 	sizeOfSuperblock := binary.Size(SuperBlock{})
-	sizeOfInodo := binary.Size(Inode{})
+	sizeOfInodo := tamanoInodo
 	sizeOfBloque := binary.Size(Fileblock{})
 
+	// En EXT3 cada inodo reserva además una entrada del journal
+	var filesystemType, sizeOfJournal int
+	switch fsType {
+	case "ext2":
+		filesystemType = 2
+	case "ext3":
+		filesystemType = 3
+		sizeOfJournal = binary.Size(Journal{})
+	default:
+		return progreso, fmt.Errorf("Tipo de sistema de archivos no soportado: %s", fsType)
+	}
+
 	// Tamaño de la partición
 	partitionSize := int(partition.Partition.PartS)
 	numerator := partitionSize - sizeOfSuperblock
-	denominator := 4 + sizeOfInodo + 3*sizeOfBloque
+	denominator := 4 + sizeOfJournal + sizeOfInodo + 3*sizeOfBloque
 	n := numerator / denominator
 	if n < 1 {
 		n = 1
@@ -206,56 +319,69 @@ func formatPartition(id, fsType string, full bool) error {
 	inodeCount := n
 	blockCount := 3 * n
 
-	var superblock SuperBlock
-	if fsType == "ext2" {
-		superblock = SuperBlock{
-			FilesystemType:  2,
-			InodesCount:     int32(inodeCount),
-			BlocksCount:     int32(blockCount),
-			FreeBlocksCount: int32(blockCount),
-			FreeInodesCount: int32(inodeCount),
-			MountCount:      1,
-			Magic:           0xEF53,
-			InodeSize:       int32(sizeOfInodo),
-			BlockSize:       int32(sizeOfBloque),
-			BmInodeStart:    int32(int(partition.Partition.PartStart) + sizeOfSuperblock),
-			BmBlockStart:    int32(int(partition.Partition.PartStart) + sizeOfSuperblock + inodeCount),
-			InodeStart:      int32(int(partition.Partition.PartStart) + sizeOfSuperblock + inodeCount + blockCount),
-			BlockStart:      int32(int(partition.Partition.PartStart) + sizeOfSuperblock + inodeCount + blockCount + (inodeCount * sizeOfInodo)),
+	// El journal va justo después del SuperBlock y antes de los bitmaps
+	start := int(partition.Partition.PartStart) + sizeOfSuperblock + n*sizeOfJournal
+
+	superblock := SuperBlock{
+		FilesystemType:  int32(filesystemType),
+		InodesCount:     int32(inodeCount),
+		BlocksCount:     int32(blockCount),
+		FreeBlocksCount: int32(blockCount),
+		FreeInodesCount: int32(inodeCount),
+		MountCount:      1,
+		Magic:           0xEF53,
+		InodeSize:       int32(sizeOfInodo),
+		BlockSize:       int32(sizeOfBloque),
+		BmInodeStart:    int32(start),
+		BmBlockStart:    int32(start + inodeCount),
+		InodeStart:      int32(start + inodeCount + blockCount),
+		BlockStart:      int32(start + inodeCount + blockCount + (inodeCount * sizeOfInodo)),
+	}
+	copy(superblock.MountTime[:], time.Now().Format("2006-01-02 15:04:05"))
+	copy(superblock.UnmountTime[:], time.Now().Format("2006-01-02 15:04:05"))
+
+	// Limpiar el journal de un formateo anterior
+	if sizeOfJournal > 0 {
+		journal := make([]byte, n*sizeOfJournal)
+		if _, err := file.WriteAt(journal, inicioJournal(partition.Partition.PartStart)); err != nil {
+			return progreso, fmt.Errorf("Error al inicializar el journal: %v", err)
 		}
-		copy(superblock.MountTime[:], time.Now().Format("2006-01-02 15:04:05"))
-		copy(superblock.UnmountTime[:], time.Now().Format("2006-01-02 15:04:05"))
-	} else {
-		return fmt.Errorf("Tipo de sistema de archivos no soportado: %s", fsType)
 	}
 
-	// Escribir el superblock en el inicio de la partición
+	// Inicializar mapas de bits de inodos y bloques
+	if err := initializeBitmaps(file, &superblock); err != nil {
+		return progreso, fmt.Errorf("Error al inicializar los mapas de bits: %v", err)
+	}
+
+	// Crear la carpeta raíz y el archivo users.txt con el contenido inicial
+	if err := createUsersFile(file, &superblock); err != nil {
+		return progreso, fmt.Errorf("Error al crear el archivo users.txt: %v", err)
+	}
+
+	// Escribir el superblock en el inicio de la partición con los contadores actualizados
 	if _, err := file.Seek(partition.Partition.PartStart, 0); err != nil {
-		return fmt.Errorf("Error al posicionarse en el inicio de la partición: %v", err)
+		return progreso, fmt.Errorf("Error al posicionarse en el inicio de la partición: %v", err)
 	}
 	if err := binary.Write(file, binary.LittleEndian, &superblock); err != nil {
-		return fmt.Errorf("Error al escribir el superblock: %v", err)
+		return progreso, fmt.Errorf("Error al escribir el superblock: %v", err)
 	}
 
-	// Inicializar mapas de bits de inodos y bloques
-	if err := initializeBitmaps(file, &superblock); err != nil {
-		return fmt.Errorf("Error al inicializar los mapas de bits: %v", err)
+	// El formateo es la primera entrada del journal; recovery parte de ella
+	tipo := "fast"
+	if full {
+		tipo = "full"
 	}
-
-	// Crear el archivo users.txt con el contenido inicial
-	if err := createUsersFile(file, int(superblock.BlockStart)); err != nil {
-		return fmt.Errorf("Error al crear el archivo users.txt: %v", err)
+	parametros := fmt.Sprintf("-type=%s -fs=%dfs", tipo, filesystemType)
+	if err := registrarJournal(file, &superblock, partition.Partition.PartStart, "mkfs", "/", parametros); err != nil {
+		return progreso, err
 	}
 
 	fmt.Printf("Partición con ID '%s' formateada a %s con éxito.\n", id, fsType)
-	return nil
+	return progreso, nil
 }
 
 /*----------------------------------------------LOGIN----------------------------------------------*/
-var users = make(map[string]User)
-var sesionActiva = false
-var usuarioActual string
-var idSesionActual int
+// Los usuarios cargados y la sesión activa se guardan en el estado del motor
 
 // Analiza el comando LOGIN y extrae los parámetros
 func parseLoginCommand(command string) (username, password, id string, err error) {
@@ -306,67 +432,84 @@ func isPartitionMountedByID(id string) bool {
 	id = strings.ToLower(id)
 
 	// Verificar si el ID existe en el mapa de particiones montadas
-	_, exists := mountedPartitions[id]
+	_, exists := motor.montaje(id)
 
 	return exists
 }
 
-// Función para cargar usuarios desde users.txt
-func loadUsers(file *os.File, blockStart int) error {
-	if _, err := file.Seek(int64(blockStart), 0); err != nil {
-		return fmt.Errorf("Error al posicionarse para leer users.txt: %v", err)
+// Función para cargar usuarios desde users.txt (inodo 1 de la partición). Los
+// grupos y usuarios eliminados (ID 0) se ignoran.
+func loadUsers(file *os.File, superblock *SuperBlock) (map[string]User, error) {
+	inodo, err := leerInodo(file, superblock, inodoUsuarios)
+	if err != nil {
+		return nil, fmt.Errorf("Error al leer el inodo de users.txt: %v", err)
 	}
-
-	content := make([]byte, 1024)
-	n, err := file.Read(content)
+	fs := &sistemaArchivos{file: file, superblock: superblock}
+	content, err := fs.leerContenido(inodo)
 	if err != nil {
-		return fmt.Errorf("Error al leer users.txt: %v", err)
+		return nil, fmt.Errorf("Error al leer users.txt: %v", err)
 	}
-	fmt.Printf("Contenido leído de users.txt: %s\n", string(content[:n]))
-	lines := strings.Split(strings.TrimSpace(string(content[:n])), "\n")
+	users := make(map[string]User)
+	groups := make(map[string]int)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
 	for _, line := range lines {
 		parts := strings.Split(line, ",")
 		if len(parts) == 3 && parts[1] == "G" {
-			continue
-		} else if len(parts) == 5 && parts[1] == "U" {
+			gid, _ := strconv.Atoi(parts[0])
+			if gid != 0 {
+				groups[strings.TrimSpace(parts[2])] = gid
+			}
+		}
+	}
+	for _, line := range lines {
+		parts := strings.Split(line, ",")
+		if len(parts) == 5 && parts[1] == "U" {
 			id, _ := strconv.Atoi(parts[0])
+			if id == 0 {
+				continue
+			}
 			username := strings.TrimSpace(parts[3])
 			users[username] = User{
 				ID:       id,
+				GID:      groups[strings.TrimSpace(parts[2])],
 				Username: username,
 				Password: strings.TrimSpace(parts[4]),
 				Group:    strings.TrimSpace(parts[2]),
 			}
-			// Depuración: Verificar los usuarios cargados
-			fmt.Printf("Usuario cargado: %+v\n", users[username])
 		}
 	}
-	return nil
+	return users, nil
 }
 
 // Función de inicio de sesión
 func login(username, password string, id string) error {
-	// Verificar si ya hay una sesión activa
-	if sesionActiva {
-		return errors.New("ya hay una sesión activa, cierre sesión antes de iniciar una nueva")
-	}
-
-	// Verificar si el usuario existe en el mapa cargado desde users.txt
-	user, exists := users[username]
+	id = strings.ToLower(id)
+	partition, exists := motor.montaje(id)
 	if !exists {
-		fmt.Println("Usuarios cargados:", users) // Depuración: Verifica el contenido del mapa de usuarios
-		return errors.New("usuario no encontrado")
+		return fmt.Errorf("partición con ID '%s' no está montada", id)
 	}
 
-	// Verificar la contraseña
-	if user.Password != password {
-		return errors.New("contraseña incorrecta")
+	// Leer los usuarios desde users.txt de la partición
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDONLY, 0)
+	if err != nil {
+		return fmt.Errorf("Error al abrir el disco: %v", err)
+	}
+	superblock, err := leerSuperBloque(file, partition.Partition.PartStart)
+	if err != nil {
+		cerrar()
+		return err
+	}
+	users, err := loadUsers(file, superblock)
+	cerrar()
+	if err != nil {
+		return err
 	}
 
-	// Marcar la sesión como activa
-	sesionActiva = true
-	usuarioActual = username
-	idSesionActual = user.ID
+	// La verificación de la sesión activa y de la contraseña se hace dentro del
+	// motor para que dos logins simultáneos no abran dos sesiones
+	if err := motor.iniciarSesion(users, username, password, id); err != nil {
+		return err
+	}
 
 	fmt.Printf("Sesión iniciada con éxito. Bienvenido, %s.\n", username)
 	return nil
@@ -398,15 +541,10 @@ func parseLogoutCommand(command2 string) (log string, err error) {
 
 // Cierra la sesión actual
 func logout() error {
-	if !sesionActiva {
-		return errors.New("no hay una sesión activa para cerrar")
+	if err := motor.cerrarSesion(); err != nil {
+		return err
 	}
 
-	// Resetea la sesión
-	sesionActiva = false
-	usuarioActual = ""
-	idSesionActual = 0
-
 	fmt.Println("Sesión cerrada con éxito.")
 	return nil
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const inicioParticionPrueba = 512
+
+// Crea un disco temporal lleno con relleno y registra en el motor una partición
+// primaria montada de tamano bytes que empieza en el byte 512. El disco tiene 512
+// bytes más después de la partición para comprobar que no se escriben.
+func prepararParticion(t *testing.T, id string, tamano int64, relleno byte) MountedPartition {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	contenido := bytes.Repeat([]byte{relleno}, int(inicioParticionPrueba+tamano+512))
+	if err := os.WriteFile(path, contenido, 0644); err != nil {
+		t.Fatal(err)
+	}
+	particion := MountedPartition{
+		ID:   id,
+		Path: path,
+		Partition: Partition1{
+			PartStatus: '1',
+			PartType:   'p',
+			PartStart:  inicioParticionPrueba,
+			PartS:      tamano,
+		},
+	}
+	if !motor.agregarMontaje(particion) {
+		t.Fatalf("el ID de montaje '%s' ya está en uso", id)
+	}
+	t.Cleanup(func() { motor.quitarMontaje(id) })
+	return particion
+}
+
+// Inicia sesión como root en la partición de prueba
+func iniciarSesionPrueba(t *testing.T, id string) {
+	t.Helper()
+	usuarios := map[string]User{"root": {ID: uidRoot, GID: 1, Username: "root", Password: "123", Group: "root"}}
+	if err := motor.iniciarSesion(usuarios, "root", "123", id); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { motor.cerrarSesion() })
+}
+
+// Cuenta los inodos y bloques libres según los bitmaps
+func contarLibresBitmaps(t *testing.T, fs *sistemaArchivos) (int32, int32) {
+	t.Helper()
+	contar := func(inicio, total int32) int32 {
+		bitmap := make([]byte, total)
+		if _, err := fs.file.ReadAt(bitmap, int64(inicio)); err != nil {
+			t.Fatal(err)
+		}
+		return int32(bytes.Count(bitmap, []byte{0}))
+	}
+	sb := fs.superblock
+	return contar(sb.BmInodeStart, sb.InodesCount), contar(sb.BmBlockStart, sb.BlocksCount)
+}
+
+// Verifica que los contadores de libres del SuperBlock coincidan con los bitmaps
+func verificarContadores(t *testing.T, fs *sistemaArchivos) {
+	t.Helper()
+	inodos, bloques := contarLibresBitmaps(t, fs)
+	if inodos != fs.superblock.FreeInodesCount || bloques != fs.superblock.FreeBlocksCount {
+		t.Fatalf("bitmaps con %d inodos y %d bloques libres, SuperBlock con %d y %d",
+			inodos, bloques, fs.superblock.FreeInodesCount, fs.superblock.FreeBlocksCount)
+	}
+}
+
+func TestFormatPartition(t *testing.T) {
+	const tamano = 200 * 1024
+	const relleno = 0xAA
+	for _, fsType := range []string{"ext2", "ext3"} {
+		for _, full := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s full=%t", fsType, full), func(t *testing.T) {
+				particion := prepararParticion(t, "990a", tamano, relleno)
+				progreso, err := formatPartition(particion.ID, fsType, full)
+				if err != nil {
+					t.Fatalf("formatPartition: %v", err)
+				}
+
+				if full {
+					if len(progreso) != 10 || !strings.HasSuffix(progreso[len(progreso)-1], "100%") {
+						t.Errorf("avance inesperado del formateo completo: %q", progreso)
+					}
+				} else if len(progreso) != 0 {
+					t.Errorf("el formateo rápido informó avance: %q", progreso)
+				}
+
+				disco, err := os.ReadFile(particion.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fin := inicioParticionPrueba + tamano
+				if !todosIguales(disco[:inicioParticionPrueba], relleno) || !todosIguales(disco[fin:], relleno) {
+					t.Fatal("el formateo escribió fuera de la partición")
+				}
+
+				file, err := os.Open(particion.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+				sb, err := leerSuperBloque(file, inicioParticionPrueba)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Bloques sin usar (users.txt ocupa los bloques 0 y 1) y el resto de la
+				// partición después del último bloque
+				libres := disco[int(sb.BlockStart)+2*int(sb.BlockSize) : fin]
+				if full && !todosIguales(libres, 0) {
+					t.Error("el formateo completo no limpió los bloques de datos")
+				}
+				if !full && !todosIguales(libres, relleno) {
+					t.Error("el formateo rápido modificó los bloques de datos")
+				}
+
+				verificarSistemaFormateado(t, particion.ID, fsType)
+			})
+		}
+	}
+}
+
+func TestFormatPartitionSoloLectura(t *testing.T) {
+	particion := prepararParticion(t, "990a", 64*1024, 0)
+	motor.quitarMontaje(particion.ID)
+	particion.ReadOnly = true
+	motor.agregarMontaje(particion)
+	if _, err := formatPartition(particion.ID, "ext2", true); err == nil {
+		t.Fatal("se formateó una partición montada en solo lectura")
+	}
+}
+
+// Verifica que la partición formateada se pueda abrir y tenga la carpeta raíz, el
+// archivo users.txt y los contadores iniciales
+func verificarSistemaFormateado(t *testing.T, id, fsType string) {
+	t.Helper()
+	iniciarSesionPrueba(t, id)
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		sb := fs.superblock
+		tipo := map[string]int32{"ext2": 2, "ext3": 3}[fsType]
+		if sb.FilesystemType != tipo {
+			t.Errorf("FilesystemType = %d, se esperaba %d", sb.FilesystemType, tipo)
+		}
+		if sb.FreeInodesCount != sb.InodesCount-2 || sb.FreeBlocksCount != sb.BlocksCount-2 {
+			t.Errorf("contadores iniciales inesperados: %+v", *sb)
+		}
+		verificarContadores(t, fs)
+
+		raiz, err := fs.leerInodo(inodoRaiz)
+		if err != nil {
+			return err
+		}
+		if raiz.Type != DirType {
+			t.Errorf("el inodo raíz no es una carpeta")
+		}
+		contenido, err := fs.leerArchivo("/users.txt")
+		if err != nil {
+			return err
+		}
+		if string(contenido) != "1,G,root\n1,U,root,root,123\n" {
+			t.Errorf("contenido de users.txt inesperado: %q", contenido)
+		}
+
+		entradas, err := leerJournal(fs.file, sb, fs.inicio)
+		if err != nil {
+			return err
+		}
+		if tipo == 3 && (len(entradas) == 0 || strings.Trim(string(entradas[0].Operation[:]), "\x00") != "mkfs") {
+			t.Errorf("la primera entrada del journal no es mkfs: %+v", entradas)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func todosIguales(data []byte, valor byte) bool {
+	for _, b := range data {
+		if b != valor {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------MKFILE--------------------------------------
+// Analiza el comando mkfile: -path obligatorio, -size con el tamaño del contenido
+// generado, -cont con la ruta de un archivo del servidor y -r para crear las carpetas
+// padre que no existan
+func parseMkfileCommand(command string) (path string, size int, cont string, recursivo bool, err error) {
+	parametros, err := leerParametros(command, "path", "size", "cont", "r")
+	if err != nil {
+		return "", 0, "", false, err
+	}
+	path = parametros["path"]
+	cont = parametros["cont"]
+	_, recursivo = parametros["r"]
+	if valor, existe := parametros["size"]; existe {
+		size, err = strconv.Atoi(valor)
+		if err != nil || size < 0 {
+			return "", 0, "", false, fmt.Errorf("el parámetro -size debe ser un entero mayor o igual a 0")
+		}
+	}
+	if path == "" {
+		return "", 0, "", false, fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	return path, size, cont, recursivo, nil
+}
+
+// Contenido de un archivo nuevo: el del archivo -cont del servidor si se indicó, o
+// size bytes con el patrón 0123456789
+func contenidoMkfile(size int, cont string) ([]byte, error) {
+	if cont != "" {
+		data, err := os.ReadFile(cont)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer el archivo '%s': %v", cont, err)
+		}
+		return data, nil
+	}
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte('0' + i%10)
+	}
+	return data, nil
+}
+
+// Comando mkfile: crea el archivo en la partición de la sesión activa
+func crearArchivo(path string, size int, cont string, recursivo bool) error {
+	contenido, err := contenidoMkfile(size, cont)
+	if err != nil {
+		return err
+	}
+	// El contenido de un archivo del servidor se guarda en el journal, porque
+	// recovery no puede volver a leerlo; el patrón se genera de nuevo con -size
+	parametros := fmt.Sprintf("-size=%d", size)
+	var datos []byte
+	if cont != "" {
+		parametros = fmt.Sprintf("-size=%d -datos", len(contenido))
+		datos = contenido
+	}
+	if recursivo {
+		parametros += " -r"
+	}
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournalDatos("mkfile", path, parametros, datos, func() error {
+			return fs.crearArchivo(path, contenido, recursivo)
+		})
+	})
+}
+
+// Crea un archivo con el contenido indicado. Con recursivo se crean las carpetas
+// padre que no existan; si después no se puede crear el archivo, se quitan.
+func (fs *sistemaArchivos) crearArchivo(ruta string, contenido []byte, recursivo bool) error {
+	rutaPadre, nombre, err := separarRuta(ruta)
+	if err != nil {
+		return err
+	}
+
+	indicePadre, padre, err := fs.resolverRuta(rutaPadre)
+	if err == nil {
+		return fs.crearArchivoEn(indicePadre, padre, rutaPadre, nombre, contenido)
+	}
+	if !recursivo {
+		return fmt.Errorf("%w, use -r para crear las carpetas padre", err)
+	}
+	creada, err := fs.crearCarpetas(rutaPadre, true)
+	if err == nil {
+		if indicePadre, padre, err = fs.resolverRuta(rutaPadre); err == nil {
+			err = fs.crearArchivoEn(indicePadre, padre, rutaPadre, nombre, contenido)
+		}
+	}
+	if err != nil && creada != nil {
+		return fs.deshacerCreacion(err, creada)
+	}
+	return err
+}
+
+// Crea el archivo nombre con el contenido dentro de la carpeta padre de la ruta
+// rutaPadre, verificando permisos y espacio
+func (fs *sistemaArchivos) crearArchivoEn(indicePadre int32, padre *Inode, rutaPadre, nombre string, contenido []byte) error {
+	if padre.Type != DirType {
+		return fmt.Errorf("'%s' no es una carpeta", rutaPadre)
+	}
+	if err := fs.autorizar(padre, Write|Exec, rutaPadre); err != nil {
+		return err
+	}
+	existente, err := fs.buscarEnCarpeta(padre, nombre)
+	if err != nil {
+		return err
+	}
+	if existente != -1 {
+		return fmt.Errorf("ya existe '%s'", filepath.Join(rutaPadre, nombre))
+	}
+
+	necesarios, err := bloquesContenido(contenido)
+	if err != nil {
+		return err
+	}
+	if err := fs.verificarEspacioEn(padre, 1, necesarios); err != nil {
+		return fmt.Errorf("no se puede crear '%s': %w", filepath.Join(rutaPadre, nombre), err)
+	}
+	_, err = fs.nuevoArchivo(indicePadre, padre, nombre, contenido)
+	return err
+}
+
+// Crea el inodo de un archivo con el contenido indicado y lo agrega a la carpeta
+// padre. Devuelve el índice del inodo nuevo. Si algo falla se liberan el inodo y
+// los bloques que alcanzó a reservar.
+func (fs *sistemaArchivos) nuevoArchivo(indicePadre int32, padre *Inode, nombre string, contenido []byte) (int32, error) {
+	indice, err := fs.reservarInodo()
+	if err != nil {
+		return -1, err
+	}
+	archivo := nuevoInodo(int32(fs.sesion.ID), int32(fs.sesion.GID), FileType, "664")
+	if err := fs.escribirContenido(&archivo, contenido); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &archivo)
+	}
+	if err := fs.escribirInodo(indice, &archivo); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &archivo)
+	}
+	if err := fs.agregarEntrada(indicePadre, padre, nombre, indice); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &archivo)
+	}
+	return indice, nil
+}
+
+// Bloques que ocupa un archivo con el contenido, incluidos los de apuntadores
+func bloquesContenido(contenido []byte) (int, error) {
+	tamanoBloque := len(Fileblock{}.B_content)
+	necesarios := bloquesNecesarios((len(contenido) + tamanoBloque - 1) / tamanoBloque)
+	if necesarios < 0 {
+		return 0, fmt.Errorf("el contenido de %d bytes supera el tamaño máximo de un archivo", len(contenido))
+	}
+	return necesarios, nil
+}
+
+// Verifica que el contenido quepa en un archivo y en los bloques libres de la
+// partición más liberados, los bloques que se liberarán antes de escribirlo
+func (fs *sistemaArchivos) verificarEspacio(contenido []byte, liberados int) error {
+	necesarios, err := bloquesContenido(contenido)
+	if err != nil {
+		return err
+	}
+	if int32(necesarios) > fs.superblock.FreeBlocksCount+int32(liberados) {
+		return fmt.Errorf("no hay bloques libres suficientes para %d bytes", len(contenido))
+	}
+	return nil
+}
+
+// Verifica que haya inodos y bloques libres para crear algo dentro de la carpeta
+// padre, contando también los bloques que padre necesite para la entrada nueva
+func (fs *sistemaArchivos) verificarEspacioEn(padre *Inode, inodos, bloques int) error {
+	agregados, err := fs.bloquesNuevaEntrada(padre)
+	if err != nil {
+		return err
+	}
+	if int32(inodos) > fs.superblock.FreeInodesCount {
+		return fmt.Errorf("no hay inodos libres suficientes")
+	}
+	if int32(bloques+agregados) > fs.superblock.FreeBlocksCount {
+		return fmt.Errorf("no hay bloques libres suficientes")
+	}
+	return nil
+}
+
+// Escribe el contenido de un archivo vacío en bloques de 64 bytes y actualiza su
+// tamaño. El inodo se modifica en memoria; quien llama debe escribirlo.
+func (fs *sistemaArchivos) escribirContenido(archivo *Inode, contenido []byte) error {
+	tamanoBloque := len(Fileblock{}.B_content)
+	for i := 0; i < len(contenido); i += tamanoBloque {
+		indice, err := fs.agregarBloque(archivo)
+		if err != nil {
+			return err
+		}
+		var bloque Fileblock
+		copy(bloque.B_content[:], contenido[i:])
+		if err := escribirBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return err
+		}
+	}
+	archivo.Size = int64(len(contenido))
+	archivo.MTIME = fechaActual()
+	return nil
+}
+
+// Lee el contenido completo de un archivo
+func (fs *sistemaArchivos) leerContenido(archivo *Inode) ([]byte, error) {
+	bloques, err := fs.bloquesDatos(archivo)
+	if err != nil {
+		return nil, err
+	}
+	contenido := make([]byte, 0, archivo.Size)
+	restante := archivo.Size
+	for _, indice := range bloques {
+		if restante <= 0 {
+			break
+		}
+		var bloque Fileblock
+		if err := leerBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return nil, err
+		}
+		n := int64(len(bloque.B_content))
+		if restante < n {
+			n = restante
+		}
+		contenido = append(contenido, bloque.B_content[:n]...)
+		restante -= n
+	}
+	return contenido, nil
+}
+
+// ----------------------------------------CAT----------------------------------------
+// Analiza el comando cat: -file1, -file2, ... en orden; se requiere al menos -file1
+// y los números deben ser consecutivos
+func parseCatCommand(command string) ([]string, error) {
+	parametros, err := leerParametros(command)
+	if err != nil {
+		return nil, err
+	}
+	archivos := make([]string, 0, len(parametros))
+	for i := 1; ; i++ {
+		path, existe := parametros[fmt.Sprintf("file%d", i)]
+		if !existe {
+			break
+		}
+		if path == "" {
+			return nil, fmt.Errorf("el parámetro -file%d no tiene una ruta", i)
+		}
+		archivos = append(archivos, path)
+	}
+	if len(archivos) == 0 {
+		return nil, fmt.Errorf("el parámetro -file1 es obligatorio")
+	}
+	if len(archivos) != len(parametros) {
+		return nil, fmt.Errorf("parámetros inválidos: se esperan -file1, -file2, ... consecutivos")
+	}
+	return archivos, nil
+}
+
+// Comando cat: devuelve el contenido de los archivos separados por un salto de línea
+func leerArchivos(paths []string) (string, error) {
+	contenidos := make([]string, 0, len(paths))
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		for _, path := range paths {
+			contenido, err := fs.leerArchivo(path)
+			if err != nil {
+				return err
+			}
+			contenidos = append(contenidos, string(contenido))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(contenidos, "\n"), nil
+}
+
+// Lee el contenido del archivo de la ruta si el usuario tiene permiso de lectura
+func (fs *sistemaArchivos) leerArchivo(ruta string) ([]byte, error) {
+	_, archivo, err := fs.resolverRuta(ruta)
+	if err != nil {
+		return nil, err
+	}
+	if archivo.Type != FileType {
+		return nil, fmt.Errorf("'%s' no es un archivo", ruta)
+	}
+	if err := fs.autorizar(archivo, Read, ruta); err != nil {
+		return nil, err
+	}
+	return fs.leerContenido(archivo)
+}
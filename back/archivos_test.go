@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Inodos y bloques libres según los bitmaps de la partición de prueba
+func libresPrueba(t *testing.T) (int32, int32) {
+	t.Helper()
+	var inodos, bloques int32
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		verificarContadores(t, fs)
+		inodos, bloques = contarLibresBitmaps(t, fs)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inodos, bloques
+}
+
+// Si nuevoArchivo falla a mitad de la escritura o al agregar la entrada, se liberan
+// el inodo y todos los bloques que alcanzó a reservar
+func TestNuevoArchivoLiberaLaReservaSiFalla(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	// La raíz queda sin espacios libres
+	if err := crearCarpeta("/x", false); err != nil {
+		t.Fatal(err)
+	}
+	agotarBloques(t, 5)
+	inodos, bloques := libresPrueba(t)
+
+	casos := map[string][]byte{
+		// 20 bloques de datos y uno de apuntadores: se acaban los bloques al escribir
+		"contenido": bytes.Repeat([]byte("a"), 20*64),
+		// El contenido cabe pero la raíz no tiene bloque para la entrada
+		"entrada": bytes.Repeat([]byte("b"), 5*64),
+	}
+	for nombre, contenido := range casos {
+		err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+			raiz, err := fs.leerInodo(inodoRaiz)
+			if err != nil {
+				return err
+			}
+			if _, err := fs.nuevoArchivo(inodoRaiz, raiz, "a.txt", contenido); err == nil {
+				t.Errorf("%s: nuevoArchivo no informó la falta de bloques", nombre)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i, b := libresPrueba(t); i != inodos || b != bloques {
+			t.Errorf("%s: quedan %d inodos y %d bloques libres, antes había %d y %d", nombre, i, b, inodos, bloques)
+		}
+	}
+	if existeRuta(t, "/a.txt") {
+		t.Error("el archivo fallido quedó en la raíz")
+	}
+}
+
+// mkfile, mkdir, copy y move cuentan el bloque que necesita la carpeta padre para
+// la entrada nueva y fallan antes de reservar nada
+func TestCrearCuentaElCrecimientoDeLaCarpeta(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	// Con /d la raíz queda sin espacios libres
+	if err := crearArchivo("/d/a.txt", 64, "", true); err != nil {
+		t.Fatal(err)
+	}
+	// Alcanza para el contenido o la carpeta nueva, pero no para el bloque de la raíz
+	agotarBloques(t, 1)
+	inodos, bloques := libresPrueba(t)
+
+	if err := crearArchivo("/b.txt", 64, "", false); err == nil {
+		t.Error("mkfile no contó el bloque de la raíz")
+	}
+	if err := crearCarpeta("/y", false); err == nil {
+		t.Error("mkdir no contó el bloque de la raíz")
+	}
+	if err := copiarArchivo("/d/a.txt", "/"); err == nil {
+		t.Error("copy no contó el bloque de la raíz")
+	}
+	if i, b := libresPrueba(t); i != inodos || b != bloques {
+		t.Errorf("quedan %d inodos y %d bloques libres, antes había %d y %d", i, b, inodos, bloques)
+	}
+	for _, ruta := range []string{"/b.txt", "/y", "/a.txt"} {
+		if existeRuta(t, ruta) {
+			t.Errorf("se creó %s", ruta)
+		}
+	}
+
+	// En una carpeta con espacio libre el mismo bloque alcanza
+	if err := crearArchivo("/d/b.txt", 64, "", false); err != nil {
+		t.Fatal(err)
+	}
+	// Sin bloques libres la raíz no puede recibir /d/b.txt
+	if err := moverArchivo("/d/b.txt", "/"); err == nil {
+		t.Error("move no contó el bloque de la raíz")
+	}
+	if !existeRuta(t, "/d/b.txt") || existeRuta(t, "/b.txt") {
+		t.Error("move rechazado cambió el archivo de lugar")
+	}
+}
+
+// Si mkfile -r crea las carpetas padre pero el archivo no cabe, se quitan
+func TestCrearArchivoRecursivoDeshaceSiFalla(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	// Alcanza para /p y /p/q, pero no para el contenido
+	agotarBloques(t, 4)
+	inodos, bloques := libresPrueba(t)
+
+	if err := crearArchivo("/p/q/f.txt", 10*64, "", true); err == nil {
+		t.Fatal("mkfile -r no informó la falta de bloques")
+	}
+	if existeRuta(t, "/p") {
+		t.Error("quedó la carpeta padre /p")
+	}
+	if i, b := libresPrueba(t); i != inodos || b != bloques {
+		t.Errorf("quedan %d inodos y %d bloques libres, antes había %d y %d", i, b, inodos, bloques)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import "fmt"
+
+// Capa de autorización del sistema de archivos. Todas las operaciones sobre inodos
+// consultan aquí los permisos UGO: se usan los del dueño si el UID de la sesión es
+// el del inodo, los del grupo si coincide el GID y los de otros en cualquier otro
+// caso. root tiene todos los permisos. Para atravesar una carpeta al resolver una
+// ruta se necesita el permiso de ejecución sobre ella.
+
+// Errores de sesión y de permisos de las operaciones del sistema de archivos
+var (
+	ErrSinSesion        = nuevoErrorCodigo("SESION_INACTIVA", "no hay una sesión activa, inicie sesión con login")
+	ErrPermisoLectura   = nuevoErrorCodigo("PERM_LECTURA", "permiso de lectura denegado")
+	ErrPermisoEscritura = nuevoErrorCodigo("PERM_ESCRITURA", "permiso de escritura denegado")
+	ErrPermisoEjecucion = nuevoErrorCodigo("PERM_EJECUCION", "permiso de ejecución denegado")
+	ErrNoPropietario    = nuevoErrorCodigo("PERM_PROPIETARIO", "solo root o el dueño pueden modificar el inodo")
+	ErrSoloRoot         = nuevoErrorCodigo("PERM_SOLO_ROOT", "solo el usuario root puede ejecutar el comando")
+)
+
+// Error de cada permiso, en el orden en que se verifican
+var erroresPermiso = []struct {
+	permiso byte
+	err     *errorCodigo
+}{
+	{Read, ErrPermisoLectura},
+	{Write, ErrPermisoEscritura},
+	{Exec, ErrPermisoEjecucion},
+}
+
+// Indica si la sesión tiene todos los permisos indicados (Read, Write, Exec o su
+// combinación) sobre el inodo
+func (fs *sistemaArchivos) tienePermiso(inodo *Inode, permisos byte) bool {
+	if fs.sesion.ID == uidRoot {
+		return true
+	}
+	digito := inodo.Perms[2] // Otros
+	if int(inodo.UID) == fs.sesion.ID {
+		digito = inodo.Perms[0]
+	} else if int(inodo.GID) == fs.sesion.GID {
+		digito = inodo.Perms[1]
+	}
+	return (digito-'0')&permisos == permisos
+}
+
+// Verifica que la sesión tenga los permisos sobre el inodo de la ruta. Devuelve el
+// error del primer permiso que falte.
+func (fs *sistemaArchivos) autorizar(inodo *Inode, permisos byte, ruta string) error {
+	for _, p := range erroresPermiso {
+		if permisos&p.permiso != 0 && !fs.tienePermiso(inodo, p.permiso) {
+			return fmt.Errorf("%w: %s", p.err, ruta)
+		}
+	}
+	return nil
+}
+
+// Permisos necesarios para leer el inodo al recorrer un árbol: un archivo se lee
+// con Read y una carpeta además se atraviesa con Exec
+func permisosLectura(inodo *Inode) byte {
+	if inodo.Type == DirType {
+		return Read | Exec
+	}
+	return Read
+}
+
+// Indica si la sesión puede cambiar los permisos y el dueño del inodo: solo root y
+// el dueño pueden hacerlo
+func (fs *sistemaArchivos) esPropietario(inodo *Inode) bool {
+	return fs.sesion.ID == uidRoot || int(inodo.UID) == fs.sesion.ID
+}
+
+func (fs *sistemaArchivos) autorizarPropietario(inodo *Inode, ruta string) error {
+	if !fs.esPropietario(inodo) {
+		return fmt.Errorf("%w: %s", ErrNoPropietario, ruta)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Cierra la sesión de root e inicia sesión con otro usuario de users.txt
+func cambiarSesionPrueba(t *testing.T, usuario, password string) {
+	t.Helper()
+	if err := motor.cerrarSesion(); err != nil {
+		t.Fatal(err)
+	}
+	if err := login(usuario, password, "990a"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Permisos del inodo de la ruta
+func permisosRuta(t *testing.T, ruta string) string {
+	t.Helper()
+	var permisos string
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		_, inodo, err := fs.resolverRuta(ruta)
+		if err != nil {
+			return err
+		}
+		permisos = string(inodo.Perms[:])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return permisos
+}
+
+func TestPermisosIniciales(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	if err := crearArchivo("/docs/a.txt", 5, "", true); err != nil {
+		t.Fatal(err)
+	}
+	esperados := map[string]string{"/": "775", "/users.txt": "664", "/docs": "775", "/docs/a.txt": "664"}
+	for ruta, esperado := range esperados {
+		if permisos := permisosRuta(t, ruta); permisos != esperado {
+			t.Errorf("permisos de %s = %s, se esperaba %s", ruta, permisos, esperado)
+		}
+	}
+}
+
+// Sin permiso de ejecución sobre una carpeta no se puede usar nada de su interior,
+// aunque los permisos del archivo lo permitan
+func TestPermisoEjecucionAlAtravesarCarpetas(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	if err := crearGrupo("devs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearUsuario("ana", "abc", "devs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/priv/sub/a.txt", 5, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := cambiarPermisos("/priv", "777", true); err != nil {
+		t.Fatal(err)
+	}
+	// ana entra en "otros": puede leer y escribir /priv pero no atravesarla
+	if err := cambiarPermisos("/priv", "776", false); err != nil {
+		t.Fatal(err)
+	}
+	cambiarSesionPrueba(t, "ana", "abc")
+
+	operaciones := map[string]func() error{
+		"cat":    func() error { _, err := leerArchivos([]string{"/priv/sub/a.txt"}); return err },
+		"mkfile": func() error { return crearArchivo("/priv/sub/b.txt", 1, "", false) },
+		"mkdir":  func() error { return crearCarpeta("/priv/sub/otra", false) },
+		"rename": func() error { return renombrarArchivo("/priv/sub/a.txt", "b.txt") },
+		"remove": func() error { return eliminarArchivo("/priv/sub") },
+		"move":   func() error { return moverArchivo("/priv/sub", "/") },
+		"copy":   func() error { return copiarArchivo("/priv", "/") },
+		"find":   func() error { _, err := buscarArchivos("/priv", "*"); return err },
+	}
+	for nombre, operacion := range operaciones {
+		if err := operacion(); !errors.Is(err, ErrPermisoEjecucion) {
+			t.Errorf("%s dentro de una carpeta sin permiso de ejecución: %v", nombre, err)
+		}
+	}
+
+	// Con el permiso de ejecución las mismas rutas son accesibles
+	cambiarSesionPrueba(t, "root", "123")
+	if err := cambiarPermisos("/priv", "777", false); err != nil {
+		t.Fatal(err)
+	}
+	cambiarSesionPrueba(t, "ana", "abc")
+	contenido, err := leerArchivos([]string{"/priv/sub/a.txt"})
+	if err != nil || contenido != "01234" {
+		t.Fatalf("cat con permiso de ejecución: %q, %v", contenido, err)
+	}
+	if err := crearCarpeta("/priv/sub/otra", false); err != nil {
+		t.Fatal(err)
+	}
+	if permisos := permisosRuta(t, "/priv/sub/otra"); permisos != "775" {
+		t.Errorf("permisos de la carpeta creada por ana = %s", permisos)
+	}
+}
+
+// Las carpetas sin permiso de lectura y ejecución no se recorren en find y copy,
+// y remove no elimina nada si no puede recorrer el árbol
+func TestRecorridoDeCarpetasSinEjecucion(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	if err := crearGrupo("devs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearUsuario("ana", "abc", "devs"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ruta := range []string{"/p/x/a.txt", "/p/x/cerrada/b.txt"} {
+		if err := crearArchivo(ruta, 3, "", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cambiarPermisos("/p", "777", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := cambiarPermisos("/p/x/cerrada", "776", false); err != nil {
+		t.Fatal(err)
+	}
+	cambiarSesionPrueba(t, "ana", "abc")
+
+	arbol, err := buscarArchivos("/p", "*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(arbol, "b.txt") || !strings.Contains(arbol, "a.txt") {
+		t.Errorf("find recorrió una carpeta sin permiso de ejecución:\n%s", arbol)
+	}
+
+	if err := crearCarpeta("/p/copia", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := copiarArchivo("/p/x/cerrada", "/p/copia"); !errors.Is(err, ErrPermisoEjecucion) {
+		t.Errorf("copy de una carpeta sin permiso de ejecución: %v", err)
+	}
+
+	if err := eliminarArchivo("/p/x"); !errors.Is(err, ErrPermisoEjecucion) {
+		t.Errorf("remove de un árbol con una carpeta sin permiso de ejecución: %v", err)
+	}
+	if !existeRuta(t, "/p/x/a.txt") {
+		t.Error("remove rechazado eliminó parte del árbol")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------FIND---------------------------------------
+// Analiza el comando find: -path con la carpeta donde empieza la búsqueda y -name
+// con el patrón del nombre, donde * representa cualquier cantidad de caracteres y ?
+// un solo carácter
+func parseFindCommand(command string) (path, name string, err error) {
+	parametros, err := leerParametros(command, "path", "name")
+	if err != nil {
+		return "", "", err
+	}
+	path, name = parametros["path"], parametros["name"]
+	if path == "" {
+		return "", "", fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("el parámetro -name es obligatorio")
+	}
+	return path, name, nil
+}
+
+// Comando find: devuelve en forma de árbol las entradas cuyo nombre coincide con el
+// patrón, junto con las carpetas que las contienen
+func buscarArchivos(path, name string) (string, error) {
+	var lineas []string
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		_, inicio, err := fs.resolverRuta(path)
+		if err != nil {
+			return err
+		}
+		if inicio.Type != DirType {
+			return fmt.Errorf("'%s' no es una carpeta", path)
+		}
+		if err := fs.autorizar(inicio, Read|Exec, path); err != nil {
+			return err
+		}
+		if lineas, err = fs.buscar(inicio, name, 1); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(lineas) == 0 {
+		return "", fmt.Errorf("no se encontraron coincidencias para '%s' en '%s'", name, path)
+	}
+	return strings.Join(append([]string{path}, lineas...), "\n"), nil
+}
+
+// Busca dentro de la carpeta las entradas que coinciden con el patrón y devuelve las
+// líneas del árbol con la sangría del nivel. Una subcarpeta aparece si coincide o si
+// contiene coincidencias; las carpetas sin permiso de lectura y ejecución no se
+// recorren.
+func (fs *sistemaArchivos) buscar(carpeta *Inode, patron string, nivel int) ([]string, error) {
+	entradas, err := fs.entradasCarpeta(carpeta)
+	if err != nil {
+		return nil, err
+	}
+	sangria := strings.Repeat("  ", nivel-1) + "|_ "
+	var lineas []string
+	for _, entrada := range entradas {
+		if entrada.Nombre == "." || entrada.Nombre == ".." {
+			continue
+		}
+		inodo, err := fs.leerInodo(entrada.Inodo)
+		if err != nil {
+			return nil, err
+		}
+		coincide := coincidePatron(patron, entrada.Nombre)
+
+		var internas []string
+		if inodo.Type == DirType && fs.tienePermiso(inodo, Read|Exec) {
+			if internas, err = fs.buscar(inodo, patron, nivel+1); err != nil {
+				return nil, err
+			}
+		}
+		if !coincide && len(internas) == 0 {
+			continue
+		}
+		nombre := entrada.Nombre
+		if inodo.Type == DirType {
+			nombre += "/"
+		}
+		lineas = append(lineas, sangria+nombre)
+		lineas = append(lineas, internas...)
+	}
+	return lineas, nil
+}
+
+// Indica si el nombre coincide con el patrón: * representa cualquier cantidad de
+// caracteres (incluso ninguno) y ? exactamente un carácter
+func coincidePatron(patron, nombre string) bool {
+	p, n := []rune(patron), []rune(nombre)
+	// Posición del último * visto y de la parte del nombre que cubre
+	estrella, cubierto := -1, 0
+	i, j := 0, 0
+	for j < len(n) {
+		switch {
+		case i < len(p) && (p[i] == '?' || p[i] == n[j]):
+			i++
+			j++
+		case i < len(p) && p[i] == '*':
+			estrella, cubierto = i, j
+			i++
+		case estrella != -1:
+			// El último * cubre un carácter más
+			cubierto++
+			i, j = estrella+1, cubierto
+		default:
+			return false
+		}
+	}
+	for i < len(p) && p[i] == '*' {
+		i++
+	}
+	return i == len(p)
+}
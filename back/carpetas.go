@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------MKDIR---------------------------------------
+// Analiza el comando mkdir del sistema de archivos: -path obligatorio y -p para
+// crear las carpetas padre que no existan
+func parseMkdirCarpetaCommand(command string) (path string, padres bool, err error) {
+	parametros, err := leerParametros(command, "path", "p")
+	if err != nil {
+		return "", false, err
+	}
+	path = parametros["path"]
+	_, padres = parametros["p"]
+	if path == "" {
+		return "", false, fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	return path, padres, nil
+}
+
+// Comando mkdir: crea la carpeta en la partición de la sesión activa
+func crearCarpeta(path string, padres bool) error {
+	contenido := ""
+	if padres {
+		contenido = "-p"
+	}
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("mkdir", path, contenido, func() error {
+			return fs.crearCarpeta(path, padres)
+		})
+	})
+}
+
+// Crea la carpeta de la ruta. Con padres se crean también las carpetas intermedias
+// que no existan; sin padres deben existir todas. Si falla después de crear alguna
+// carpeta intermedia, se quitan las que creó.
+func (fs *sistemaArchivos) crearCarpeta(ruta string, padres bool) error {
+	creada, err := fs.crearCarpetas(ruta, padres)
+	if err != nil && creada != nil {
+		return fs.deshacerCreacion(err, creada)
+	}
+	return err
+}
+
+// Entrada que creó una operación dentro de la carpeta Padre, que ya existía
+type entradaCreada struct {
+	Padre  int32
+	Nombre string
+}
+
+// Crea las carpetas de la ruta como crearCarpeta y devuelve la primera que creó,
+// también cuando falla, o nil si no alcanzó a crear ninguna
+func (fs *sistemaArchivos) crearCarpetas(ruta string, padres bool) (*entradaCreada, error) {
+	nombres, err := dividirRuta(ruta)
+	if err != nil {
+		return nil, err
+	}
+	if len(nombres) == 0 {
+		return nil, fmt.Errorf("la carpeta raíz ya existe")
+	}
+
+	var creada *entradaCreada
+	indice := int32(inodoRaiz)
+	carpeta, err := fs.leerInodo(indice)
+	if err != nil {
+		return nil, err
+	}
+	for i, nombre := range nombres {
+		actual := "/" + strings.Join(nombres[:i+1], "/")
+		if carpeta.Type != DirType {
+			return creada, fmt.Errorf("'/%s' no es una carpeta", strings.Join(nombres[:i], "/"))
+		}
+		if err := fs.autorizar(carpeta, Exec, "/"+strings.Join(nombres[:i], "/")); err != nil {
+			return creada, err
+		}
+		siguiente, err := fs.buscarEnCarpeta(carpeta, nombre)
+		if err != nil {
+			return creada, err
+		}
+		ultimo := i == len(nombres)-1
+
+		if siguiente != -1 {
+			if ultimo {
+				return creada, fmt.Errorf("ya existe '%s'", actual)
+			}
+			indice = siguiente
+			if carpeta, err = fs.leerInodo(indice); err != nil {
+				return creada, err
+			}
+			continue
+		}
+
+		if !ultimo && !padres {
+			return creada, fmt.Errorf("no existe la carpeta '%s', use -p para crearla", actual)
+		}
+		if err := fs.autorizar(carpeta, Write, "/"+strings.Join(nombres[:i], "/")); err != nil {
+			return creada, err
+		}
+		if err := fs.verificarEspacioEn(carpeta, 1, 1); err != nil {
+			return creada, fmt.Errorf("no se puede crear '%s': %w", actual, err)
+		}
+		nueva, err := fs.nuevaCarpeta(indice, carpeta, nombre)
+		if err != nil {
+			return creada, err
+		}
+		if creada == nil {
+			creada = &entradaCreada{Padre: indice, Nombre: nombre}
+		}
+		indice = nueva
+		if carpeta, err = fs.leerInodo(indice); err != nil {
+			return creada, err
+		}
+	}
+	return creada, nil
+}
+
+// Crea una carpeta vacía dentro de padre y devuelve su inodo. La carpeta pertenece
+// al usuario y al grupo de la sesión. Quien llama verifica el permiso de escritura
+// sobre padre. Si algo falla se liberan el inodo y el bloque reservados.
+func (fs *sistemaArchivos) nuevaCarpeta(indicePadre int32, padre *Inode, nombre string) (int32, error) {
+	indice, err := fs.reservarInodo()
+	if err != nil {
+		return -1, err
+	}
+	carpeta := nuevoInodo(int32(fs.sesion.ID), int32(fs.sesion.GID), DirType, "775")
+	bloque, err := fs.reservarBloque()
+	if err != nil {
+		return -1, fs.deshacerReserva(err, indice, &carpeta)
+	}
+
+	carpeta.Blocks[0] = bloque
+	contenido := nuevoFolderBlock()
+	copy(contenido.B_content[0].Name[:], ".")
+	contenido.B_content[0].Inode = indice
+	copy(contenido.B_content[1].Name[:], "..")
+	contenido.B_content[1].Inode = indicePadre
+
+	if err := escribirBloque(fs.file, fs.superblock, bloque, &contenido); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &carpeta)
+	}
+	if err := fs.escribirInodo(indice, &carpeta); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &carpeta)
+	}
+	if err := fs.agregarEntrada(indicePadre, padre, nombre, indice); err != nil {
+		return -1, fs.deshacerReserva(err, indice, &carpeta)
+	}
+	return indice, nil
+}
+
+// Quita lo que creó una operación que falló después. Devuelve err, el error de la
+// operación.
+func (fs *sistemaArchivos) deshacerCreacion(err error, creada *entradaCreada) error {
+	if errQuitar := fs.quitarCreada(creada); errQuitar != nil {
+		return fmt.Errorf("%w; además no se pudo quitar '%s': %v", err, creada.Nombre, errQuitar)
+	}
+	return err
+}
+
+// Quita la entrada creada de su carpeta padre, si alcanzó a agregarse, y libera
+// todo lo que contiene
+func (fs *sistemaArchivos) quitarCreada(creada *entradaCreada) error {
+	padre, err := fs.leerInodo(creada.Padre)
+	if err != nil {
+		return err
+	}
+	indice, err := fs.buscarEnCarpeta(padre, creada.Nombre)
+	if err != nil || indice == -1 {
+		return err
+	}
+	if err := fs.quitarEntrada(creada.Padre, padre, creada.Nombre); err != nil {
+		return err
+	}
+	return fs.liberarArbol(indice)
+}
+
+// Libera los bloques que alcanzó a reservar el inodo en memoria, incluidos los de
+// apuntadores, y el propio inodo cuando no se pudo crear. Devuelve err, el error
+// que lo impidió.
+func (fs *sistemaArchivos) deshacerReserva(err error, indice int32, inodo *Inode) error {
+	if errLiberar := fs.liberarBloques(inodo); errLiberar != nil {
+		return fmt.Errorf("%w; además no se pudieron liberar los bloques del inodo %d: %v", err, indice, errLiberar)
+	}
+	if errLiberar := fs.liberarInodo(indice); errLiberar != nil {
+		return fmt.Errorf("%w; además no se pudo liberar el inodo %d: %v", err, indice, errLiberar)
+	}
+	return err
+}
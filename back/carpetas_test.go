@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+)
+
+// Deja la partición de prueba con libres bloques libres reservando el resto
+func agotarBloques(t *testing.T, libres int32) {
+	t.Helper()
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		for fs.superblock.FreeBlocksCount > libres {
+			if _, err := fs.reservarBloque(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Si la carpeta padre no puede crecer para la entrada nueva, mkdir falla sin dejar
+// reservados el inodo ni el bloque de la carpeta
+func TestNuevaCarpetaLiberaLaReservaSiFalla(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	// La raíz tiene ".", "..", users.txt y un espacio libre que ocupa /x
+	if err := crearCarpeta("/x", false); err != nil {
+		t.Fatal(err)
+	}
+	// Un bloque alcanza para la carpeta nueva pero no para el bloque que necesita la raíz
+	agotarBloques(t, 1)
+
+	var inodos, bloques int32
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		inodos, bloques = contarLibresBitmaps(t, fs)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		raiz, err := fs.leerInodo(inodoRaiz)
+		if err != nil {
+			return err
+		}
+		if _, err := fs.nuevaCarpeta(inodoRaiz, raiz, "y"); err == nil {
+			t.Error("nuevaCarpeta no informó la falta de bloques para la raíz")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		verificarContadores(t, fs)
+		if i, b := contarLibresBitmaps(t, fs); i != inodos || b != bloques {
+			t.Errorf("quedan %d inodos y %d bloques libres, antes había %d y %d", i, b, inodos, bloques)
+		}
+		raiz, err := fs.leerInodo(inodoRaiz)
+		if err != nil {
+			return err
+		}
+		if existente, err := fs.buscarEnCarpeta(raiz, "y"); err != nil || existente != -1 {
+			t.Errorf("la carpeta fallida quedó en la raíz: %d, %v", existente, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Con el espacio de la raíz disponible la carpeta se crea
+	if err := eliminarArchivo("/x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearCarpeta("/y", false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Si mkdir -p falla a mitad de la ruta se quitan las carpetas intermedias que creó.
+// El bloque que ganó la raíz para la entrada queda en la raíz.
+func TestCrearCarpetaConPadresDeshaceSiFalla(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	// Con /x la raíz queda sin espacios libres
+	if err := crearCarpeta("/x", false); err != nil {
+		t.Fatal(err)
+	}
+	// Alcanza para el bloque de la raíz, /a y /a/b, pero no para /a/b/c
+	agotarBloques(t, 3)
+	inodos, bloques := libresPrueba(t)
+
+	if err := crearCarpeta("/a/b/c", true); err == nil {
+		t.Fatal("mkdir -p no informó la falta de bloques")
+	}
+	if existeRuta(t, "/a") {
+		t.Error("quedó la carpeta intermedia /a")
+	}
+	if i, b := libresPrueba(t); i != inodos || b != bloques-1 {
+		t.Errorf("quedan %d inodos y %d bloques libres, se esperaban %d y %d", i, b, inodos, bloques-1)
+	}
+
+	// La raíz usa el bloque que conservó
+	if err := crearCarpeta("/y", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, b := libresPrueba(t); b != bloques-2 {
+		t.Errorf("quedan %d bloques libres después de /y, se esperaban %d", b, bloques-2)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const archivoConfiguracion = "config.json"
+
+// Configuración del servidor. Se lee de config.json en el directorio de datos (una
+// por espacio de trabajo) y las variables de entorno MIA_CARNET y MIA_ID_PATTERN la
+// sobrescriben (una por servidor).
+type Configuracion struct {
+	Carnet   string `json:"carnet"`     // Carnet usado en los IDs de montaje
+	PatronID string `json:"id_pattern"` // Formato de los IDs de montaje
+}
+
+var configuracion = cargarConfiguracion()
+
+func cargarConfiguracion() Configuracion {
+	config := Configuracion{
+		Carnet:   "201900603",
+		PatronID: "{carnet2}{n}{L}",
+	}
+
+	data, err := os.ReadFile(filepath.Join(directorioDatos, archivoConfiguracion))
+	if err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			fmt.Println("Error al leer la configuración:", err)
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Println("Error al leer la configuración:", err)
+	}
+
+	if carnet := os.Getenv("MIA_CARNET"); carnet != "" {
+		config.Carnet = carnet
+	}
+	if patron := os.Getenv("MIA_ID_PATTERN"); patron != "" {
+		config.PatronID = patron
+	}
+
+	if err := validarPatronID(config.PatronID, config.Carnet); err != nil {
+		fmt.Println("Error en el patrón de IDs de montaje, se usará el predeterminado:", err)
+		config.PatronID = "{carnet2}{n}{L}"
+	}
+	return config
+}
+
+// Valida un patrón de IDs de montaje. Los marcadores disponibles son:
+//
+//	{carnet}  carnet completo
+//	{carnet2} últimos dos dígitos del carnet
+//	{n}       número de la partición dentro del disco
+//	{L}, {l}  letra del disco en mayúscula o minúscula
+//
+// El patrón debe incluir {n} para distinguir las particiones de un mismo disco, y
+// con el carnet sus IDs deben caber en PartId. {n} se cuenta con un dígito; si una
+// partición recibe un número mayor y su ID no cabe, el montaje se rechaza.
+func validarPatronID(patron, carnet string) error {
+	if !strings.Contains(patron, "{n}") {
+		return fmt.Errorf("el patrón '%s' no incluye {n}", patron)
+	}
+	resto := patron
+	for _, marcador := range []string{"{carnet2}", "{carnet}", "{n}", "{L}", "{l}"} {
+		resto = strings.ReplaceAll(resto, marcador, "")
+	}
+	if strings.ContainsAny(resto, "{}") {
+		return fmt.Errorf("el patrón '%s' tiene marcadores desconocidos", patron)
+	}
+	maximo := len(Partition1{}.PartId)
+	if id := formatearIDMontaje(patron, carnet, 9, 'A'); len(id) > maximo {
+		return fmt.Errorf("el patrón '%s' con el carnet '%s' genera IDs como '%s', de más de %d caracteres", patron, carnet, strings.ToLower(id), maximo)
+	}
+	return nil
+}
+
+// Aplica el patrón de IDs de montaje
+func formatearIDMontaje(patron, carnet string, numero int32, letra byte) string {
+	carnet2 := carnet
+	if len(carnet2) > 2 {
+		carnet2 = carnet2[len(carnet2)-2:]
+	}
+	id := strings.ReplaceAll(patron, "{carnet2}", carnet2)
+	id = strings.ReplaceAll(id, "{carnet}", carnet)
+	id = strings.ReplaceAll(id, "{n}", fmt.Sprintf("%d", numero))
+	id = strings.ReplaceAll(id, "{L}", strings.ToUpper(string(letra)))
+	id = strings.ReplaceAll(id, "{l}", strings.ToLower(string(letra)))
+	return id
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Carga la configuración de un config.json de prueba con las variables de entorno
+// indicadas; un archivo vacío significa que no hay config.json
+func cargarConfiguracionPrueba(t *testing.T, archivo string, entorno map[string]string) Configuracion {
+	t.Helper()
+	anterior := directorioDatos
+	directorioDatos = t.TempDir()
+	t.Cleanup(func() { directorioDatos = anterior })
+	if archivo != "" {
+		if err := os.WriteFile(filepath.Join(directorioDatos, archivoConfiguracion), []byte(archivo), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, variable := range []string{"MIA_CARNET", "MIA_ID_PATTERN"} {
+		t.Setenv(variable, entorno[variable])
+	}
+	return cargarConfiguracion()
+}
+
+func TestCargarConfiguracion(t *testing.T) {
+	casos := []struct {
+		nombre, archivo string
+		entorno         map[string]string
+		carnet, patron  string
+	}{
+		{"predeterminada", "", nil, "201900603", "{carnet2}{n}{L}"},
+		{"archivo", `{"carnet": "202012345", "id_pattern": "{n}{carnet2}{l}"}`, nil, "202012345", "{n}{carnet2}{l}"},
+		{"carnet completo que cabe", `{"carnet": "12", "id_pattern": "{carnet}{n}{L}"}`, nil, "12", "{carnet}{n}{L}"},
+		// IDs como 2019006031a: el patrón se reemplaza por el predeterminado
+		{"archivo con IDs largos", `{"id_pattern": "{carnet}{n}{l}"}`, nil, "201900603", "{carnet2}{n}{L}"},
+		{"archivo sin {n}", `{"id_pattern": "{carnet2}{L}"}`, nil, "201900603", "{carnet2}{n}{L}"},
+		{"archivo inválido", `{"carnet": `, nil, "201900603", "{carnet2}{n}{L}"},
+		{
+			"entorno sobre el archivo", `{"carnet": "202012345", "id_pattern": "{n}{carnet2}{l}"}`,
+			map[string]string{"MIA_CARNET": "99", "MIA_ID_PATTERN": "{carnet}{L}{n}"}, "99", "{carnet}{L}{n}",
+		},
+		// El carnet del entorno hace que el patrón del archivo genere IDs largos
+		{
+			"carnet del entorno largo", `{"carnet": "12", "id_pattern": "{carnet}{n}{L}"}`,
+			map[string]string{"MIA_CARNET": "123"}, "123", "{carnet2}{n}{L}",
+		},
+		{"patrón del entorno largo", "", map[string]string{"MIA_ID_PATTERN": "id{carnet2}{n}"}, "201900603", "{carnet2}{n}{L}"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			config := cargarConfiguracionPrueba(t, caso.archivo, caso.entorno)
+			if config.Carnet != caso.carnet || config.PatronID != caso.patron {
+				t.Errorf("configuración %+v, se esperaba carnet %q y patrón %q", config, caso.carnet, caso.patron)
+			}
+		})
+	}
+}
+
+func TestValidarPatronID(t *testing.T) {
+	casos := []struct {
+		patron, carnet string
+		valido         bool
+	}{
+		{"{carnet2}{n}{L}", "201900603", true},
+		{"{n}{l}", "201900603", true},
+		{"{carnet}{n}", "123", true},
+		{"{carnet}{n}{L}", "123", false},
+		{"{carnet}{n}", "201900603", false},
+		{"ab{carnet2}{n}", "201900603", false},
+		{"{carnet2}{L}", "201900603", false},
+		{"{carnet2}{n}{x}", "201900603", false},
+	}
+	for _, caso := range casos {
+		if err := validarPatronID(caso.patron, caso.carnet); (err == nil) != caso.valido {
+			t.Errorf("validarPatronID(%q, %q) = %v, válido: %t", caso.patron, caso.carnet, err, caso.valido)
+		}
+	}
+}
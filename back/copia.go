@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// ------------------------------------COPY-Y-MOVE------------------------------------
+// Analiza los comandos copy y move: -path del archivo o carpeta de origen y -destino
+// con la carpeta a la que se copia o mueve
+func parseCopyMoveCommand(command string) (path, destino string, err error) {
+	parametros, err := leerParametros(command, "path", "destino")
+	if err != nil {
+		return "", "", err
+	}
+	path, destino = parametros["path"], parametros["destino"]
+	if path == "" {
+		return "", "", fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if destino == "" {
+		return "", "", fmt.Errorf("el parámetro -destino es obligatorio")
+	}
+	return path, destino, nil
+}
+
+// Comando copy: copia el archivo o la carpeta con su contenido dentro del destino
+func copiarArchivo(path, destino string) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("copy", path, fmt.Sprintf("-destino=\"%s\"", destino), func() error {
+			return fs.copiar(path, destino)
+		})
+	})
+}
+
+// Comando move: mueve el archivo o la carpeta dentro del destino
+func moverArchivo(path, destino string) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("move", path, fmt.Sprintf("-destino=\"%s\"", destino), func() error {
+			return fs.mover(path, destino)
+		})
+	})
+}
+
+// Busca la carpeta de destino de copy y move y verifica que se pueda escribir en
+// ella y atravesarla y que no tenga ya una entrada con el nombre
+func (fs *sistemaArchivos) resolverDestino(destino, nombre string) (int32, *Inode, error) {
+	indice, carpeta, err := fs.resolverRuta(destino)
+	if err != nil {
+		return -1, nil, err
+	}
+	if carpeta.Type != DirType {
+		return -1, nil, fmt.Errorf("el destino '%s' no es una carpeta", destino)
+	}
+	if err := fs.autorizar(carpeta, Write|Exec, destino); err != nil {
+		return -1, nil, err
+	}
+	existente, err := fs.buscarEnCarpeta(carpeta, nombre)
+	if err != nil {
+		return -1, nil, err
+	}
+	if existente != -1 {
+		return -1, nil, fmt.Errorf("ya existe '%s'", path.Join(destino, nombre))
+	}
+	return indice, carpeta, nil
+}
+
+// Indica si la carpeta ancestro contiene a la carpeta indice (o es la misma),
+// subiendo por las entradas ".." hasta la raíz
+func (fs *sistemaArchivos) contieneCarpeta(ancestro, indice int32) (bool, error) {
+	for {
+		if indice == ancestro {
+			return true, nil
+		}
+		if indice == inodoRaiz {
+			return false, nil
+		}
+		carpeta, err := fs.leerInodo(indice)
+		if err != nil {
+			return false, err
+		}
+		if indice, err = fs.buscarEnCarpeta(carpeta, ".."); err != nil {
+			return false, err
+		}
+		if indice == -1 {
+			return false, fmt.Errorf("la carpeta no tiene entrada '..'")
+		}
+	}
+}
+
+// Copia el archivo o la carpeta de la ruta dentro del destino. Las entradas que el
+// usuario no puede leer (o atravesar, si son carpetas) no se copian. Los inodos nuevos pertenecen al usuario de la
+// sesión y conservan los permisos del original.
+func (fs *sistemaArchivos) copiar(ruta, destino string) error {
+	_, nombre, err := separarRuta(ruta)
+	if err != nil {
+		return err
+	}
+	indice, origen, err := fs.resolverRuta(ruta)
+	if err != nil {
+		return err
+	}
+	if err := fs.autorizar(origen, permisosLectura(origen), ruta); err != nil {
+		return err
+	}
+	indiceDestino, carpetaDestino, err := fs.resolverDestino(destino, nombre)
+	if err != nil {
+		return err
+	}
+	if origen.Type == DirType {
+		dentro, err := fs.contieneCarpeta(indice, indiceDestino)
+		if err != nil {
+			return err
+		}
+		if dentro {
+			return fmt.Errorf("no se puede copiar '%s' dentro de sí misma", ruta)
+		}
+	}
+
+	// Verificar el espacio antes de copiar para no dejar una copia a medias
+	inodos, bloques, err := fs.contarCopia(origen)
+	if err != nil {
+		return err
+	}
+	if err := fs.verificarEspacioEn(carpetaDestino, inodos, bloques); err != nil {
+		return fmt.Errorf("no hay espacio suficiente para copiar '%s': %w", ruta, err)
+	}
+	// Si la copia falla a la mitad se quita lo que alcanzó a copiar
+	if err := fs.copiarArbol(origen, indiceDestino, carpetaDestino, nombre); err != nil {
+		return fs.deshacerCreacion(err, &entradaCreada{Padre: indiceDestino, Nombre: nombre})
+	}
+	return nil
+}
+
+// Inodos y bloques que ocupa la copia de las entradas legibles del inodo
+func (fs *sistemaArchivos) contarCopia(inodo *Inode) (int, int, error) {
+	tamanoBloque := int64(len(Fileblock{}.B_content))
+	if inodo.Type != DirType {
+		return 1, bloquesNecesarios(int((inodo.Size + tamanoBloque - 1) / tamanoBloque)), nil
+	}
+
+	entradas, err := fs.entradasCarpeta(inodo)
+	if err != nil {
+		return 0, 0, err
+	}
+	inodos, bloques, copiadas := 1, 0, 2 // "." y ".."
+	for _, entrada := range entradas {
+		if entrada.Nombre == "." || entrada.Nombre == ".." {
+			continue
+		}
+		hijo, err := fs.leerInodo(entrada.Inodo)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !fs.tienePermiso(hijo, permisosLectura(hijo)) {
+			continue
+		}
+		i, b, err := fs.contarCopia(hijo)
+		if err != nil {
+			return 0, 0, err
+		}
+		inodos, bloques, copiadas = inodos+i, bloques+b, copiadas+1
+	}
+	porBloque := len(FolderBlock{}.B_content)
+	return inodos, bloques + bloquesNecesarios((copiadas+porBloque-1)/porBloque), nil
+}
+
+// Copia el inodo con el nombre indicado dentro de la carpeta destino
+func (fs *sistemaArchivos) copiarArbol(origen *Inode, indiceDestino int32, destino *Inode, nombre string) error {
+	if origen.Type != DirType {
+		contenido, err := fs.leerContenido(origen)
+		if err != nil {
+			return err
+		}
+		indice, err := fs.nuevoArchivo(indiceDestino, destino, nombre, contenido)
+		if err != nil {
+			return err
+		}
+		return fs.copiarPermisos(indice, origen)
+	}
+
+	indice, err := fs.nuevaCarpeta(indiceDestino, destino, nombre)
+	if err != nil {
+		return err
+	}
+	carpeta, err := fs.leerInodo(indice)
+	if err != nil {
+		return err
+	}
+	entradas, err := fs.entradasCarpeta(origen)
+	if err != nil {
+		return err
+	}
+	for _, entrada := range entradas {
+		if entrada.Nombre == "." || entrada.Nombre == ".." {
+			continue
+		}
+		hijo, err := fs.leerInodo(entrada.Inodo)
+		if err != nil {
+			return err
+		}
+		if !fs.tienePermiso(hijo, permisosLectura(hijo)) {
+			continue
+		}
+		if err := fs.copiarArbol(hijo, indice, carpeta, entrada.Nombre); err != nil {
+			return err
+		}
+	}
+	// Los permisos se copian al final para que no impidan agregar las entradas
+	return fs.copiarPermisos(indice, origen)
+}
+
+func (fs *sistemaArchivos) copiarPermisos(indice int32, origen *Inode) error {
+	inodo, err := fs.leerInodo(indice)
+	if err != nil {
+		return err
+	}
+	inodo.Perms = origen.Perms
+	return fs.escribirInodo(indice, inodo)
+}
+
+// Mueve el archivo o la carpeta de la ruta dentro del destino sin copiar sus datos:
+// la entrada se quita de la carpeta padre y se agrega al destino
+func (fs *sistemaArchivos) mover(ruta, destino string) error {
+	rutaPadre, nombre, err := separarRuta(ruta)
+	if err != nil {
+		return err
+	}
+	indicePadre, padre, err := fs.resolverRuta(rutaPadre)
+	if err != nil {
+		return err
+	}
+	if padre.Type != DirType {
+		return fmt.Errorf("'%s' no es una carpeta", rutaPadre)
+	}
+	indice, err := fs.buscarEnCarpeta(padre, nombre)
+	if err != nil {
+		return err
+	}
+	if indice == -1 {
+		return fmt.Errorf("no existe '%s'", ruta)
+	}
+	if err := protegerUsuarios(indice, ruta); err != nil {
+		return err
+	}
+	inodo, err := fs.leerInodo(indice)
+	if err != nil {
+		return err
+	}
+	if err := fs.autorizar(padre, Write|Exec, rutaPadre); err != nil {
+		return err
+	}
+	if err := fs.autorizar(inodo, Write, ruta); err != nil {
+		return err
+	}
+	indiceDestino, carpetaDestino, err := fs.resolverDestino(destino, nombre)
+	if err != nil {
+		return err
+	}
+	if inodo.Type == DirType {
+		dentro, err := fs.contieneCarpeta(indice, indiceDestino)
+		if err != nil {
+			return err
+		}
+		if dentro {
+			return fmt.Errorf("no se puede mover '%s' dentro de sí misma", ruta)
+		}
+	}
+
+	if err := fs.verificarEspacioEn(carpetaDestino, 0, 0); err != nil {
+		return fmt.Errorf("no se puede mover '%s': %w", ruta, err)
+	}
+	if err := fs.agregarEntrada(indiceDestino, carpetaDestino, nombre, indice); err != nil {
+		return err
+	}
+	if err := fs.quitarEntrada(indicePadre, padre, nombre); err != nil {
+		return err
+	}
+	if inodo.Type != DirType {
+		return nil
+	}
+	// La carpeta movida apunta a su nuevo padre
+	entrada, err := fs.buscarEntrada(inodo, "..")
+	if err != nil {
+		return err
+	}
+	if entrada == nil {
+		return fmt.Errorf("la carpeta '%s' no tiene entrada '..'", ruta)
+	}
+	contenido := BlockContent{Inode: indiceDestino}
+	copy(contenido.Name[:], "..")
+	return fs.escribirEntrada(entrada, contenido)
+}
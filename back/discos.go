@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 type EBR struct {
@@ -18,9 +19,13 @@ type EBR struct {
 }
 
 type MountedPartition struct {
-	ID        string // ID generado para la partición montada
-	Path      string // Ruta del disco
-	Partition Partition1
+	ID        string     // ID generado para la partición montada
+	Path      string     // Ruta del disco
+	Partition Partition1 // Para una lógica: inicio y tamaño del área de datos después del EBR
+	EBRStart  int64      // Posición del EBR si la partición es lógica
+	Letter    byte       // Letra asignada al disco
+	Number    int32      // Número de la partición dentro del disco
+	ReadOnly  bool       // Montada con -ro: se rechazan las escrituras
 }
 
 type Usuario struct {
@@ -39,7 +44,7 @@ func parseCommand(command string) (size int, unit, path, partitionType, fit, nam
 	// Administración de discos
 	if strings.HasPrefix(command2, "mkdisk") {
 		var size64 int64
-		size64, unit, path, fit, err = parseMkdirCommand(command2)
+		size64, unit, path, fit, _, err = parseMkdirCommand(command2)
 		//fmt.Println("Size:", size64, "Unit:", unit, "Path:", path, "Fit:", fit)
 		if err != nil {
 			fmt.Println("Error al analizar mkdisk:", err)
@@ -55,8 +60,14 @@ func parseCommand(command string) (size int, unit, path, partitionType, fit, nam
 		//fmt.Println("Path:", path)
 	} else if strings.HasPrefix(command2, "fdisk") {
 		size, unit, path, partitionType, fit, delete, name, add, err = parseFDISKCommand(command2)
+	} else if strings.HasPrefix(command2, "fsckdisk") {
+		path, _, err = parseFsckdiskCommand(command2)
+	} else if strings.HasPrefix(command2, "mounted") {
+		// No recibe parámetros
 	} else if strings.HasPrefix(command2, "mount") {
-		path, name, err = parseMountCommand(command)
+		path, name, _, err = parseMountCommand(command)
+	} else if strings.HasPrefix(command2, "unmount") {
+		id, err = parseUnmountCommand(command2)
 
 		//Administración del Sistema de Archivos
 	} else if strings.HasPrefix(command, "login") {
@@ -65,22 +76,38 @@ func parseCommand(command string) (size int, unit, path, partitionType, fit, nam
 		_, err = parseLogoutCommand(command)
 	} else if strings.HasPrefix(command2, "mkfs") {
 		id, fsType, full, err = parseMkfsCommand(command2)
-	} else if strings.HasPrefix(command, "cat") {
-		//id, fsType, full, err = parseMkfsCommand(command)
-	} else if strings.HasPrefix(command, "mkgrp") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "rmgrp") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "mkusr") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "rmusr") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "chgrp") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "mkfile") {
-		//id, path, name = parseRepCommand(command)
-	} else if strings.HasPrefix(command, "mkdir") {
-		//id, path, name = parseRepCommand(command)
+	} else if strings.HasPrefix(command2, "loss") || strings.HasPrefix(command2, "recovery") {
+		id, err = parseLossRecoveryCommand(command2)
+	} else if strings.HasPrefix(command2, "cat") {
+		_, err = parseCatCommand(command)
+	} else if strings.HasPrefix(command2, "mkgrp") {
+		name, err = parseMkgrpCommand(command)
+	} else if strings.HasPrefix(command2, "rmgrp") {
+		name, err = parseRmgrpCommand(command)
+	} else if strings.HasPrefix(command2, "mkusr") {
+		username, password, _, err = parseMkusrCommand(command)
+	} else if strings.HasPrefix(command2, "rmusr") {
+		username, err = parseRmusrCommand(command)
+	} else if strings.HasPrefix(command2, "chgrp") {
+		username, _, err = parseChgrpCommand(command)
+	} else if strings.HasPrefix(command2, "mkfile") {
+		path, _, _, _, err = parseMkfileCommand(command)
+	} else if strings.HasPrefix(command2, "edit") {
+		path, _, err = parseEditCommand(command)
+	} else if strings.HasPrefix(command2, "remove") {
+		path, err = parseRemoveCommand(command)
+	} else if strings.HasPrefix(command2, "rename") {
+		path, name, err = parseRenameCommand(command)
+	} else if strings.HasPrefix(command2, "copy") || strings.HasPrefix(command2, "move") {
+		path, _, err = parseCopyMoveCommand(command)
+	} else if strings.HasPrefix(command2, "find") {
+		path, name, err = parseFindCommand(command)
+	} else if strings.HasPrefix(command2, "chmod") {
+		path, _, _, err = parseChmodCommand(command)
+	} else if strings.HasPrefix(command2, "chown") {
+		path, _, _, err = parseChownCommand(command)
+	} else if strings.HasPrefix(command2, "mkdir") {
+		path, _, err = parseMkdirCarpetaCommand(command)
 	} else if strings.HasPrefix(command2, "rep") {
 		id, path, name, pathfile, err = parseRepCommand(command2)
 	} else {
@@ -91,18 +118,23 @@ func parseCommand(command string) (size int, unit, path, partitionType, fit, nam
 
 func crearParticionLogica(path string, size int64, name string, fit string) error {
 	// Abrir el archivo del disco
-	file, err := os.OpenFile(path, os.O_RDWR, 0666)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR, 0666)
 	if err != nil {
 		return fmt.Errorf("Error al abrir el disco: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Leer el MBR
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		return fmt.Errorf("Error al leer el MBR: %v", err)
 	}
 
+	// Validar que exista la extendida y que el nombre no se repita en el disco
+	if err := validarNuevaParticion(file, &mbr, 'l', name); err != nil {
+		return err
+	}
+
 	// Buscar la partición extendida
 	var extendedPartition Partition1
 	foundExtended := false
@@ -138,11 +170,6 @@ func crearParticionLogica(path string, size int64, name string, fit string) erro
 			break
 		}
 
-		// Verificar si ya existe una partición lógica con el mismo nombre
-		if strings.Trim(string(ebr.Name[:]), "\x00") == name {
-			return fmt.Errorf("Ya existe una partición lógica con el nombre '%s'", name)
-		}
-
 		// Continuar al siguiente EBR
 		if ebr.Next == -1 || ebr.Next == 0 {
 			prevEBR = &ebr
@@ -162,6 +189,10 @@ func crearParticionLogica(path string, size int64, name string, fit string) erro
 		newEBRStart = prevEBR.Start + prevEBR.Size
 	}
 
+	// Alinear el inicio del EBR y el tamaño según la alineación del disco
+	newEBRStart = alinear(newEBRStart, alineacionMBR(mbr))
+	size = alinear(size, alineacionMBR(mbr))
+
 	// Verificar que haya suficiente espacio
 	if newEBRStart+size > extendedPartition.PartStart+extendedPartition.PartS {
 		return fmt.Errorf("No hay suficiente espacio para la nueva partición lógica")
@@ -225,7 +256,9 @@ func imprimirPartitions(file *os.File, mbr *MBR) {
 				fmt.Println("  EBRs dentro de la partición extendida:")
 				var ebr EBR
 				currentPosition := partition.PartStart
-				for {
+				visitados := make(map[int64]bool)
+				for !visitados[currentPosition] {
+					visitados[currentPosition] = true
 					if _, err := file.Seek(currentPosition, 0); err != nil {
 						err = fmt.Errorf("Error al posicionarse en el EBR: %v", err)
 						return
@@ -245,7 +278,7 @@ func imprimirPartitions(file *os.File, mbr *MBR) {
 					fmt.Printf("  Nombre: %s\n", strings.Trim(string(ebr.Name[:]), "\x00"))
 
 					// Si no hay un siguiente EBR, salir del bucle
-					if ebr.Next == 0 {
+					if esFinCadenaEBR(ebr.Next) {
 						break
 					}
 
@@ -317,22 +350,59 @@ func crearEBR(file *os.File, start int64, size int64, name string) error {
 // Función auxiliar para encontrar el último EBR en una cadena de EBRs
 func findLastEBR(file *os.File, start int64) (*EBR, error) {
 	currentStart := start
+	visitados := make(map[int64]bool)
 
-	// Leer EBRs hasta encontrar el último, es decir, cuando Next sea 0
-	for {
+	// Leer EBRs hasta encontrar el último, es decir, cuando Next sea 0 o -1
+	for !visitados[currentStart] {
+		visitados[currentStart] = true
 		ebr, err := readEBR(file, currentStart)
 		if err != nil {
 			return nil, fmt.Errorf("error al leer el EBR: %v", err)
 		}
 
-		// Si Next es 0, este es el último EBR
-		if ebr.Next == 0 {
+		// Si Next es 0 o -1, este es el último EBR
+		if esFinCadenaEBR(ebr.Next) {
 			return ebr, nil
 		}
 
 		// Continuar con el siguiente EBR
 		currentStart = ebr.Next
 	}
+	return nil, fmt.Errorf("ciclo en la cadena de EBRs en la posición %d", currentStart)
+}
+
+// Lee la cadena de EBRs de una partición extendida sin imprimirla. Una extendida
+// sin particiones lógicas devuelve una lista vacía.
+func leerCadenaEBR(file *os.File, extendida Partition1) ([]EBR, error) {
+	var ebrs []EBR
+	visitados := make(map[int64]bool)
+	pos := extendida.PartStart
+	for {
+		if visitados[pos] {
+			return ebrs, fmt.Errorf("ciclo en la cadena de EBRs en la posición %d", pos)
+		}
+		visitados[pos] = true
+
+		ebr, err := readEBR(file, pos)
+		if err != nil {
+			return ebrs, err
+		}
+		if ebr.Size <= 0 {
+			break
+		}
+		ebrs = append(ebrs, *ebr)
+		if esFinCadenaEBR(ebr.Next) {
+			break
+		}
+		pos = ebr.Next
+	}
+	return ebrs, nil
+}
+
+// Indica si el campo Next de un EBR marca el final de la cadena.
+// crearParticionLogica termina la cadena con -1, pero crearEBR usa 0.
+func esFinCadenaEBR(next int64) bool {
+	return next == -1 || next == 0
 }
 
 // Función para escribir un EBR en una posición específica del archivo de disco
@@ -389,16 +459,22 @@ func readAllEBRs(path string, start int64) ([]EBR, error) {
 	currentStart := start
 
 	// Abrir el archivo en modo de lectura
-	file, err := os.Open(path)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDONLY, 0)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir el archivo: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
 	fmt.Printf("Leyendo EBRs desde la partición extendida en el disco: %s\n", path)
 
-	// Leer todos los EBRs hasta que el campo Next sea 0 (fin de la lista)
+	// Leer todos los EBRs hasta que el campo Next sea 0 o -1 (fin de la lista)
+	visitados := make(map[int64]bool)
 	for {
+		if visitados[currentStart] {
+			return ebrs, fmt.Errorf("ciclo en la cadena de EBRs en la posición %d", currentStart)
+		}
+		visitados[currentStart] = true
+
 		var ebr EBR
 		if _, err := file.Seek(currentStart, 0); err != nil {
 			return nil, fmt.Errorf("error al posicionarse en el archivo: %v", err)
@@ -419,8 +495,8 @@ func readAllEBRs(path string, start int64) ([]EBR, error) {
 
 		ebrs = append(ebrs, ebr)
 
-		// Si Next es 0, se ha llegado al final de los EBRs
-		if ebr.Next == 0 {
+		// Si Next es 0 o -1, se ha llegado al final de los EBRs
+		if esFinCadenaEBR(ebr.Next) {
 			break
 		}
 		currentStart = ebr.Next
@@ -436,7 +512,14 @@ func printEBRs(file *os.File, start int64) {
 
 	fmt.Println("\nEBRs en la partición extendida:")
 
+	visitados := make(map[int64]bool)
 	for {
+		if visitados[currentPosition] {
+			fmt.Println("Ciclo en la cadena de EBRs en la posición:", currentPosition)
+			return
+		}
+		visitados[currentPosition] = true
+
 		// Leer el EBR en la posición actual
 		if _, err := file.Seek(currentPosition, 0); err != nil {
 			fmt.Println("Error al posicionarse en el EBR:", err)
@@ -457,7 +540,7 @@ func printEBRs(file *os.File, start int64) {
 		fmt.Printf("  Nombre: %s\n", strings.Trim(string(ebr.Name[:]), "\x00"))
 
 		// Si no hay un siguiente EBR, salir del bucle
-		if ebr.Next == 0 {
+		if esFinCadenaEBR(ebr.Next) {
 			break
 		}
 
@@ -467,12 +550,12 @@ func printEBRs(file *os.File, start int64) {
 }
 
 // ------------------------------Montar particion -------------------------------
-func parseMountCommand(command2 string) (path, name string, err error) {
+func parseMountCommand(command2 string) (path, name string, readOnly bool, err error) {
 	//fmt.Println("Comando mount:", command2)
 	command2 = strings.ToLower(command2)
 
 	if !strings.HasPrefix(command2, "mount") {
-		return "", "", fmt.Errorf("comando no válido")
+		return "", "", false, fmt.Errorf("comando no válido")
 	}
 
 	params := strings.Split(command2[len("mount "):], " ")
@@ -483,121 +566,224 @@ func parseMountCommand(command2 string) (path, name string, err error) {
 		} else if strings.HasPrefix(param, "-name=") {
 			name = strings.TrimPrefix(param, "-name=")
 			name = strings.Trim(name, "\"")
+		} else if param == "-ro" {
+			readOnly = true
 		}
 	}
 
 	if path == "" || name == "" {
-		return "", "", fmt.Errorf("los parámetros -path y -name son obligatorios")
+		return "", "", false, fmt.Errorf("los parámetros -path y -name son obligatorios")
 	}
 
-	return path, name, nil
+	return path, name, readOnly, nil
 }
 
-var mountedPartitions = make(map[string]MountedPartition) // Mapa de particiones montadas
-var nextIDNumber = 1                                      // Número inicial de la partición
-var nextIDChar = 'A'
+// Genera el ID de una partición a montar con el patrón configurado: una letra por
+// disco y un número por partición dentro del disco. Si el disco ya tiene particiones
+// montadas se reutiliza su letra; en caso contrario se toma la menor letra libre. El
+// número es el menor que no esté en uso en el disco, así los IDs liberados se
+// reutilizan. Si el ID choca con el de otra partición de cualquier disco (por ejemplo
+// con un patrón sin letra) se prueba con el siguiente número. Se llama con m.mu
+// tomado (ver registrarMontaje).
+func (m *Motor) generatePartitionID(carnet string, path string) (string, byte, int32, error) {
+	var letra byte
+	letrasUsadas := make(map[byte]bool)
+	numerosUsados := make(map[int32]bool)
+	for _, partition := range m.montajes {
+		if partition.Path == path {
+			letra = partition.Letter
+			numerosUsados[partition.Number] = true
+		} else {
+			letrasUsadas[partition.Letter] = true
+		}
+	}
 
-func generatePartitionID(carnet string, path string) string {
-	// Obtener los últimos dos dígitos del carnet
-	lastTwoDigits := "03"
+	// Si es un disco sin particiones montadas, asignarle la menor letra libre
+	if letra == 0 {
+		for l := byte('A'); l <= 'Z'; l++ {
+			if !letrasUsadas[l] {
+				letra = l
+				break
+			}
+		}
+		if letra == 0 {
+			return "", 0, 0, fmt.Errorf("no hay letras disponibles para montar particiones de otro disco")
+		}
+	}
 
-	// Buscar si existe una partición montada del mismo disco
-	var existingPartition MountedPartition
-	for _, partition := range mountedPartitions {
-		if partition.Path == path {
-			existingPartition = partition
-			break
+	numero := int32(1)
+	for {
+		if !numerosUsados[numero] {
+			// Generar el ID en el formato configurado
+			partitionID := strings.ToLower(formatearIDMontaje(configuracion.PatronID, carnet, numero, letra))
+			if _, existe := m.montajes[partitionID]; !existe {
+				// El ID se guarda en PartId dentro del MBR
+				if len(partitionID) > len(Partition1{}.PartId) {
+					return "", 0, 0, fmt.Errorf("el ID generado '%s' excede %d caracteres, ajuste el patrón '%s'", partitionID, len(Partition1{}.PartId), configuracion.PatronID)
+				}
+				return partitionID, letra, numero, nil
+			}
 		}
+		numero++
 	}
+}
 
-	// Si hay una partición del mismo disco, incrementar el número de partición
-	if existingPartition.ID != "" {
-		nextIDNumber++
-	} else {
-		// Si es de un disco diferente, reiniciar el número y avanzar la letra
-		nextIDNumber = 1
-		nextIDChar++
-		// Reiniciar a 'A' si se ha pasado de 'Z'
-		if nextIDChar > 'Z' {
-			nextIDChar = 'A'
+// Busca una partición montable por nombre: primero entre las primarias del MBR y
+// después en la cadena de EBRs de la extendida. Devuelve el índice de la primaria
+// en el MBR, o -1 y el EBR si la partición es lógica.
+func buscarParticionMontable(file *os.File, mbr *MBR, name string) (int, *EBR, error) {
+	var extendida *Partition1
+	for i := range mbr.Partitions {
+		part := &mbr.Partitions[i]
+		if part.PartStatus == 0 {
+			continue
 		}
+		if part.PartType == 'e' {
+			extendida = part
+		}
+		if strings.Trim(string(part.PartName[:]), "\x00") != name {
+			continue
+		}
+		if part.PartType != 'p' {
+			return -1, nil, fmt.Errorf("la partición '%s' es extendida y no se puede montar", name)
+		}
+		return i, nil, nil
 	}
 
-	// Generar el ID en el formato requerido
-	partitionID := fmt.Sprintf("%s%d%c", lastTwoDigits, nextIDNumber, nextIDChar)
-	return partitionID
+	if extendida != nil {
+		ebrs, err := leerCadenaEBR(file, *extendida)
+		if err != nil {
+			return -1, nil, err
+		}
+		for i := range ebrs {
+			if strings.Trim(string(ebrs[i].Name[:]), "\x00") == name {
+				return -1, &ebrs[i], nil
+			}
+		}
+	}
+	return -1, nil, fmt.Errorf("partición '%s' no encontrada", name)
 }
 
-// Función para montar una partición primaria
-func mountPartition(path, name, carnet string) error {
+// Construye la entrada de una partición lógica montada. La partición inicia después
+// de su EBR, así el SuperBlock no sobrescribe la cadena.
+func particionLogicaMontada(ebr *EBR, partitionID string, numero int32) Partition1 {
+	sizeEBR := int64(binary.Size(EBR{}))
+	partition := Partition1{
+		PartStatus:      '1',
+		PartType:        'l',
+		PartFit:         ebr.Fit,
+		PartStart:       ebr.Start + sizeEBR,
+		PartS:           ebr.Size - sizeEBR,
+		PartName:        ebr.Name,
+		PartCorrelative: numero,
+	}
+	copy(partition.PartId[:], partitionID)
+	return partition
+}
+
+// Función para montar una partición primaria o lógica. Con readOnly la partición
+// rechaza todas las operaciones de escritura (ver verificarEscritura).
+func mountPartition(path, name, carnet string, readOnly bool) error {
 	// Abrir el archivo del disco
-	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("Error al abrir el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
+
+	// Con el disco bloqueado, verificar que la partición no se haya montado mientras
+	// tanto desde otra solicitud
+	if mounted, _ := isPartitionMounted(path, name); mounted {
+		return fmt.Errorf("La partición '%s' ya está montada", name)
+	}
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		return fmt.Errorf("Error al leer el MBR: %v", err)
 	}
 
-	// Buscar la partición por nombre dentro del MBR
-	var partition *Partition1
-	found := false
-	var partitionIndex int
-	for i := 0; i < len(mbr.Partitions); i++ {
-		part := &mbr.Partitions[i]
-		if strings.Trim(string(part.PartName[:]), "\x00") == name && part.PartType == 'p' {
-			partition = part
-			partitionIndex = i
-			found = true
-			break
-		}
+	// Las marcas que quedaron de una ejecución anterior en un disco que no estaba en
+	// la tabla de montajes no corresponden a ningún montaje actual
+	limpiadas, err := limpiarMarcasObsoletas(file, path, &mbr)
+	for _, message := range limpiadas {
+		fmt.Println(message)
 	}
-
-	// Si no se encuentra la partición, mostrar un error
-	if !found {
-		return fmt.Errorf("partición '%s' no encontrada en el disco '%s'", name, path)
+	if err != nil {
+		return err
 	}
 
-	// Generar el ID único para la partición
-	partitionID := strings.ToLower(generatePartitionID(carnet, path))
+	// Buscar la partición por nombre dentro del MBR y de los EBRs
+	partitionIndex, ebr, err := buscarParticionMontable(file, &mbr, name)
+	if err != nil {
+		return fmt.Errorf("%v en el disco '%s'", err, path)
+	}
 
-	// Actualizar el estado y el correlativo de la partición
-	partition.PartStatus = '1'                      // Marcar la partición como montada
-	partition.PartCorrelative = int32(nextIDNumber) // Asignar el número correlativo
-	copy(partition.PartId[:], partitionID[:4])      // Asignar el ID generado a la partición
+	mounted := MountedPartition{Path: path, ReadOnly: readOnly}
+	if ebr == nil {
+		mounted.Partition = mbr.Partitions[partitionIndex]
+	} else {
+		mounted.EBRStart = ebr.Start
+		mounted.Partition = particionLogicaMontada(ebr, "", 0)
+	}
 
-	// Verificar si la partición ya está montada
-	if _, exists := mountedPartitions[partitionID]; exists {
-		fmt.Println("La partición con ID", partitionID, "ya está montada")
-		return fmt.Errorf("La partición con ID '%s' ya está montada", partitionID)
+	// Generar el ID único y agregar la partición al mapa de particiones montadas
+	mounted, err = motor.registrarMontaje(carnet, mounted)
+	if err != nil {
+		return err
 	}
+	partitionID := mounted.ID
 
-	// Escribir los cambios de la partición de vuelta al MBR en el disco
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("Error al posicionarse en el inicio del archivo: %v", err)
+	if ebr == nil {
+		// Escribir el estado, el correlativo y el ID de la partición primaria en el MBR
+		mbr.Partitions[partitionIndex] = mounted.Partition
+		if err := escribirMBR(file, &mbr); err != nil {
+			motor.quitarMontaje(partitionID)
+			return fmt.Errorf("Error al escribir los cambios en el MBR: %v", err)
+		}
+	} else {
+		// Marcar el EBR de la partición lógica como montado
+		ebr.Mount = '1'
+		if err := writeEBR(file, ebr, ebr.Start); err != nil {
+			motor.quitarMontaje(partitionID)
+			return fmt.Errorf("Error al actualizar el EBR: %v", err)
+		}
 	}
-	mbr.Partitions[partitionIndex] = *partition
-	if err := binary.Write(file, binary.LittleEndian, &mbr); err != nil {
-		return fmt.Errorf("Error al escribir los cambios en el MBR: %v", err)
+
+	// Registrar el montaje en el SuperBlock si la partición ya tiene un sistema de archivos
+	if err := actualizarSuperBloqueMontaje(file, mounted.Partition.PartStart, true); err != nil {
+		return err
 	}
 
-	// Agregar la partición al mapa de particiones montadas en memoria
-	mountedPartitions[partitionID] = MountedPartition{
-		ID:        partitionID,
-		Path:      path,
-		Partition: *partition,
+	// Persistir la tabla de montajes para restaurarla al reiniciar el servidor
+	if err := guardarTablaMontajes(); err != nil {
+		fmt.Println("Error al guardar la tabla de montajes:", err)
 	}
 
 	fmt.Printf("Partición '%s' montada con ID '%s'.\n", name, partitionID)
 	return nil
 }
 
+// Error de las operaciones de escritura sobre particiones montadas con -ro
+var ErrSoloLectura = nuevoErrorCodigo("MOUNT_SOLO_LECTURA", "la partición está montada en modo solo lectura")
+
+// Rechaza las operaciones de escritura sobre una partición montada con -ro. La
+// llaman todos los comandos que modifican el sistema de archivos de la partición;
+// los reportes y las lecturas no la usan.
+func verificarEscritura(id string) error {
+	id = strings.ToLower(id)
+	partition, exists := motor.montaje(id)
+	if !exists {
+		return fmt.Errorf("partición con ID '%s' no está montada", id)
+	}
+	if partition.ReadOnly {
+		return fmt.Errorf("%w: %s", ErrSoloLectura, id)
+	}
+	return nil
+}
+
 func isPartitionMounted(path, name string) (bool, MountedPartition) {
-	for _, partition := range mountedPartitions {
+	for _, partition := range motor.listarMontajes() {
 		// Compara tanto la ruta del disco como el nombre de la partición
 		if partition.Path == path && strings.Trim(string(partition.Partition.PartName[:]), "\x00") == name {
 			return true, partition
@@ -605,3 +791,118 @@ func isPartitionMounted(path, name string) (bool, MountedPartition) {
 	}
 	return false, MountedPartition{}
 }
+
+// ------------------------------Desmontar particion -------------------------------
+func parseUnmountCommand(command2 string) (id string, err error) {
+	params := strings.Fields(strings.ToLower(command2))
+	for _, param := range params {
+		if strings.HasPrefix(param, "-id=") {
+			id = strings.TrimPrefix(param, "-id=")
+			id = strings.Trim(id, "\"")
+		}
+	}
+
+	if id == "" {
+		return "", fmt.Errorf("el parámetro -id es obligatorio")
+	}
+	return id, nil
+}
+
+// Desmonta la partición con el ID indicado: limpia PartStatus y PartId en el MBR
+// (o la marca Mount del EBR si es lógica), actualiza la fecha de desmontaje del
+// SuperBlock y la quita del mapa en memoria.
+func unmountPartition(id string) error {
+	id = strings.ToLower(id)
+	mounted, exists := motor.montaje(id)
+	if !exists {
+		return fmt.Errorf("partición con ID '%s' no está montada", id)
+	}
+
+	file, cerrar, err := motor.abrirDisco(mounted.Path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("Error al abrir el archivo del disco: %v", err)
+	}
+	defer cerrar()
+
+	// Otra solicitud pudo desmontarla mientras se esperaba el candado del disco
+	if _, exists := motor.montaje(id); !exists {
+		return fmt.Errorf("partición con ID '%s' no está montada", id)
+	}
+
+	if mounted.Partition.PartType == 'l' {
+		// Las particiones lógicas se desmontan en su EBR
+		ebr, err := readEBR(file, mounted.EBRStart)
+		if err != nil {
+			return err
+		}
+		ebr.Mount = '0'
+		if err := writeEBR(file, ebr, mounted.EBRStart); err != nil {
+			return fmt.Errorf("Error al actualizar el EBR: %v", err)
+		}
+	} else {
+		mbr, err := leerMBR(file)
+		if err != nil {
+			return fmt.Errorf("Error al leer el MBR: %v", err)
+		}
+
+		// Buscar la partición por su ID dentro del MBR
+		found := false
+		for i := range mbr.Partitions {
+			part := &mbr.Partitions[i]
+			if strings.ToLower(strings.Trim(string(part.PartId[:]), "\x00")) == id {
+				part.PartStatus = '0'
+				part.PartCorrelative = 0
+				part.PartId = [4]byte{}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("la partición con ID '%s' no se encontró en el disco '%s'", id, mounted.Path)
+		}
+
+		if err := escribirMBR(file, &mbr); err != nil {
+			return fmt.Errorf("Error al escribir los cambios en el MBR: %v", err)
+		}
+	}
+
+	// Registrar la fecha de desmontaje si la partición tiene un sistema de archivos
+	if err := actualizarSuperBloqueMontaje(file, mounted.Partition.PartStart, false); err != nil {
+		return err
+	}
+
+	motor.quitarMontaje(id)
+	if err := guardarTablaMontajes(); err != nil {
+		fmt.Println("Error al guardar la tabla de montajes:", err)
+	}
+	fmt.Printf("Partición con ID '%s' desmontada.\n", id)
+	return nil
+}
+
+// Actualiza el SuperBlock de la partición al montarla (fecha de montaje y contador)
+// o al desmontarla (fecha de desmontaje). Si la partición no tiene un sistema de
+// archivos no hace nada.
+func actualizarSuperBloqueMontaje(file *os.File, start int64, montar bool) error {
+	var superblock SuperBlock
+	if _, err := file.Seek(start, 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el super bloque: %v", err)
+	}
+	if err := binary.Read(file, binary.LittleEndian, &superblock); err != nil || superblock.Magic != 0xEF53 {
+		return nil
+	}
+
+	if montar {
+		copy(superblock.MountTime[:], time.Now().Format("2006-01-02 15:04:05"))
+		superblock.MountCount++
+	} else {
+		copy(superblock.UnmountTime[:], time.Now().Format("2006-01-02 15:04:05"))
+	}
+
+	if _, err := file.Seek(start, 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el super bloque: %v", err)
+	}
+	if err := binary.Write(file, binary.LittleEndian, &superblock); err != nil {
+		return fmt.Errorf("Error al actualizar el super bloque: %v", err)
+	}
+	return nil
+}
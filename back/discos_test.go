@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Usa el patrón de IDs predeterminado durante la prueba
+func patronPredeterminadoPrueba(t *testing.T) {
+	t.Helper()
+	anterior := configuracion
+	configuracion = Configuracion{Carnet: "201900603", PatronID: "{carnet2}{n}{L}"}
+	t.Cleanup(func() { configuracion = anterior })
+}
+
+// Registra un montaje de la partición nombre del disco path y devuelve su ID
+func registrarMontajePrueba(t *testing.T, m *Motor, path, nombre string) string {
+	t.Helper()
+	plantilla := MountedPartition{Path: path}
+	copy(plantilla.Partition.PartName[:], nombre)
+	montada, err := m.registrarMontaje("201900603", plantilla)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return montada.ID
+}
+
+// Al desmontar, el número y la letra quedan libres y el siguiente montaje usa el
+// menor disponible
+func TestGeneratePartitionIDReutilizaElMenorLibre(t *testing.T) {
+	patronPredeterminadoPrueba(t)
+	m := nuevoMotor()
+
+	ids := []string{
+		registrarMontajePrueba(t, m, "/discos/a.mia", "p1"),
+		registrarMontajePrueba(t, m, "/discos/a.mia", "p2"),
+		registrarMontajePrueba(t, m, "/discos/a.mia", "p3"),
+		registrarMontajePrueba(t, m, "/discos/b.mia", "p1"),
+	}
+	for i, esperado := range []string{"031a", "032a", "033a", "031b"} {
+		if ids[i] != esperado {
+			t.Errorf("montaje %d con ID %q, se esperaba %q", i, ids[i], esperado)
+		}
+	}
+
+	// El número 2 del disco a queda libre y se reutiliza antes que el 4
+	m.quitarMontaje("032a")
+	if id := registrarMontajePrueba(t, m, "/discos/a.mia", "p4"); id != "032a" {
+		t.Errorf("después de desmontar 032a se obtuvo %q", id)
+	}
+	if id := registrarMontajePrueba(t, m, "/discos/a.mia", "p5"); id != "034a" {
+		t.Errorf("el siguiente montaje del disco a obtuvo %q, se esperaba 034a", id)
+	}
+
+	// Sin particiones montadas el disco a deja libre la letra para otro disco
+	for _, id := range []string{"031a", "032a", "033a", "034a"} {
+		m.quitarMontaje(id)
+	}
+	if id := registrarMontajePrueba(t, m, "/discos/c.mia", "p1"); id != "031a" {
+		t.Errorf("el disco c obtuvo %q, se esperaba la letra libre 031a", id)
+	}
+}
+
+// Lee PartStatus y PartId de la primaria nombre y la marca Mount de la lógica
+// nombreLogica
+func estadoMontaje(t *testing.T, path, nombre, nombreLogica string) (byte, string, byte) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var estado byte
+	var id string
+	for _, part := range mbr.Partitions {
+		if strings.Trim(string(part.PartName[:]), "\x00") == nombre {
+			estado, id = part.PartStatus, strings.Trim(string(part.PartId[:]), "\x00")
+		}
+	}
+	var montada byte
+	for _, ebr := range leerEBRsDelMBR(file, mbr) {
+		if strings.Trim(string(ebr.Name[:]), "\x00") == nombreLogica {
+			montada = ebr.Mount
+		}
+	}
+	return estado, id, montada
+}
+
+// unmount limpia PartStatus y PartId de las primarias y la marca Mount del EBR de
+// las lógicas
+func TestUnmountLimpiaLasMarcasDelDisco(t *testing.T) {
+	patronPredeterminadoPrueba(t)
+	anterior := directorioDatos
+	directorioDatos = t.TempDir()
+	t.Cleanup(func() { directorioDatos = anterior })
+
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	crearDisco(path, 128*1024, 'F', 4096)
+	for _, particion := range []struct {
+		nombre, tipo string
+		tamano       int64
+	}{{"p1", "p", 8 * 1024}, {"ext", "e", 32 * 1024}} {
+		if err := crearParticion(path, particion.tamano, particion.nombre, particion.tipo, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := crearParticionLogica(path, 8*1024, "l1", "F"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, nombre := range []string{"p1", "l1"} {
+		if err := mountPartition(path, nombre, "201900603", false); err != nil {
+			t.Fatal(err)
+		}
+		_, montada := isPartitionMounted(path, nombre)
+		t.Cleanup(func() { motor.quitarMontaje(montada.ID) })
+		ids = append(ids, montada.ID)
+	}
+	if estado, id, montada := estadoMontaje(t, path, "p1", "l1"); estado != '1' || id != ids[0] || montada != '1' {
+		t.Fatalf("marcas después de montar: PartStatus=%c PartId=%q Mount=%c", estado, id, montada)
+	}
+
+	for _, id := range ids {
+		if err := unmountPartition(id); err != nil {
+			t.Fatal(err)
+		}
+		if _, existe := motor.montaje(id); existe {
+			t.Errorf("%s sigue en la tabla de montajes", id)
+		}
+	}
+	if estado, id, montada := estadoMontaje(t, path, "p1", "l1"); estado != '0' || id != "" || montada != '0' {
+		t.Errorf("marcas después de desmontar: PartStatus=%c PartId=%q Mount=%c", estado, id, montada)
+	}
+	if err := unmountPartition(ids[0]); err == nil {
+		t.Error("se desmontó dos veces la misma partición")
+	}
+}
+
+// Una partición montada con -ro rechaza las escrituras con ErrSoloLectura y
+// permite las lecturas
+func TestMontajeSoloLecturaRechazaEscrituras(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	particion, _ := motor.quitarMontaje("990a")
+	particion.ReadOnly = true
+	motor.agregarMontaje(particion)
+
+	if err := verificarEscritura("990A"); !errors.Is(err, ErrSoloLectura) {
+		t.Errorf("verificarEscritura = %v, se esperaba %s", err, ErrSoloLectura.Codigo)
+	}
+	if err := crearCarpeta("/docs", false); !errors.Is(err, ErrSoloLectura) {
+		t.Errorf("mkdir = %v, se esperaba %s", err, ErrSoloLectura.Codigo)
+	}
+	if err := crearGrupo("grupo"); !errors.Is(err, ErrSoloLectura) {
+		t.Errorf("mkgrp = %v, se esperaba %s", err, ErrSoloLectura.Codigo)
+	}
+	if _, err := formatPartition("990a", "ext2", false); !errors.Is(err, ErrSoloLectura) {
+		t.Errorf("mkfs = %v, se esperaba %s", err, ErrSoloLectura.Codigo)
+	}
+	if contenido, err := leerArchivos([]string{"/users.txt"}); err != nil || contenido != "1,G,root\n1,U,root,root,123\n" {
+		t.Errorf("cat en solo lectura: %q, %v", contenido, err)
+	}
+	if existeRuta(t, "/docs") {
+		t.Error("mkdir escribió en la partición de solo lectura")
+	}
+
+	if err := verificarEscritura("999z"); err == nil || errors.Is(err, ErrSoloLectura) {
+		t.Errorf("verificarEscritura de una partición no montada = %v", err)
+	}
+}
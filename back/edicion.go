@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// ----------------------------------------EDIT---------------------------------------
+// Analiza el comando edit: -path del archivo y -cont con la ruta del archivo del
+// servidor que tiene el contenido nuevo
+func parseEditCommand(command string) (path, cont string, err error) {
+	parametros, err := leerParametros(command, "path", "cont")
+	if err != nil {
+		return "", "", err
+	}
+	path, cont = parametros["path"], parametros["cont"]
+	if path == "" {
+		return "", "", fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if cont == "" {
+		return "", "", fmt.Errorf("el parámetro -cont es obligatorio")
+	}
+	return path, cont, nil
+}
+
+// Comando edit: reemplaza el contenido del archivo
+func editarArchivo(path, cont string) error {
+	contenido, err := contenidoMkfile(0, cont)
+	if err != nil {
+		return err
+	}
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		// Como en mkfile -cont, el contenido nuevo se guarda en el journal
+		return fs.conJournalDatos("edit", path, fmt.Sprintf("-size=%d -datos", len(contenido)), contenido, func() error {
+			return fs.editarArchivo(path, contenido)
+		})
+	})
+}
+
+// Reemplaza el contenido del archivo: libera sus bloques y escribe el contenido
+// nuevo en bloques recién reservados
+func (fs *sistemaArchivos) editarArchivo(ruta string, contenido []byte) error {
+	indice, archivo, err := fs.resolverRuta(ruta)
+	if err != nil {
+		return err
+	}
+	if archivo.Type != FileType {
+		return fmt.Errorf("'%s' no es un archivo", ruta)
+	}
+	if err := fs.autorizar(archivo, Read|Write, ruta); err != nil {
+		return err
+	}
+	ocupados, err := fs.contarBloques(archivo)
+	if err != nil {
+		return err
+	}
+	if err := fs.verificarEspacio(contenido, ocupados); err != nil {
+		return err
+	}
+
+	if err := fs.liberarBloques(archivo); err != nil {
+		return err
+	}
+	if err := fs.escribirContenido(archivo, contenido); err != nil {
+		return err
+	}
+	return fs.escribirInodo(indice, archivo)
+}
+
+// ---------------------------------------REMOVE--------------------------------------
+// Analiza el comando remove: -path del archivo o carpeta a eliminar
+func parseRemoveCommand(command string) (string, error) {
+	parametros, err := leerParametros(command, "path")
+	if err != nil {
+		return "", err
+	}
+	if parametros["path"] == "" {
+		return "", fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	return parametros["path"], nil
+}
+
+// Comando remove: elimina el archivo o la carpeta con todo su contenido
+func eliminarArchivo(path string) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("remove", path, "", func() error {
+			return fs.eliminar(path)
+		})
+	})
+}
+
+// Elimina el archivo o la carpeta de la ruta. Antes de liberar algo se verifica el
+// permiso de escritura sobre cada archivo y carpeta del árbol; si falta en alguno
+// no se elimina nada.
+func (fs *sistemaArchivos) eliminar(ruta string) error {
+	rutaPadre, nombre, err := separarRuta(ruta)
+	if err != nil {
+		return err
+	}
+	indicePadre, padre, err := fs.resolverRuta(rutaPadre)
+	if err != nil {
+		return err
+	}
+	if padre.Type != DirType {
+		return fmt.Errorf("'%s' no es una carpeta", rutaPadre)
+	}
+	if err := fs.autorizar(padre, Write|Exec, rutaPadre); err != nil {
+		return err
+	}
+	indice, err := fs.buscarEnCarpeta(padre, nombre)
+	if err != nil {
+		return err
+	}
+	if indice == -1 {
+		return fmt.Errorf("no existe '%s'", ruta)
+	}
+	if err := fs.verificarEliminacion(indice, ruta); err != nil {
+		return err
+	}
+	if err := fs.liberarArbol(indice); err != nil {
+		return err
+	}
+	return fs.quitarEntrada(indicePadre, padre, nombre)
+}
+
+// Verifica el permiso de escritura sobre el inodo y, si es una carpeta, el de
+// lectura y ejecución para recorrerla y el de escritura sobre todo su contenido.
+// Un árbol que contiene users.txt no se elimina.
+func (fs *sistemaArchivos) verificarEliminacion(indice int32, ruta string) error {
+	if err := protegerUsuarios(indice, ruta); err != nil {
+		return fmt.Errorf("%w, no se eliminó nada", err)
+	}
+	inodo, err := fs.leerInodo(indice)
+	if err != nil {
+		return err
+	}
+	if err := fs.autorizar(inodo, Write, ruta); err != nil {
+		return fmt.Errorf("%w, no se eliminó nada", err)
+	}
+	if inodo.Type != DirType {
+		return nil
+	}
+	if err := fs.autorizar(inodo, Read|Exec, ruta); err != nil {
+		return fmt.Errorf("%w, no se eliminó nada", err)
+	}
+	entradas, err := fs.entradasCarpeta(inodo)
+	if err != nil {
+		return err
+	}
+	for _, entrada := range entradas {
+		if entrada.Nombre == "." || entrada.Nombre == ".." {
+			continue
+		}
+		if err := fs.verificarEliminacion(entrada.Inodo, path.Join(ruta, entrada.Nombre)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// users.txt (inodo 1) no se puede eliminar, mover ni renombrar: el sistema de
+// archivos siempre lo busca en /users.txt
+func protegerUsuarios(indice int32, ruta string) error {
+	if indice == inodoUsuarios {
+		return fmt.Errorf("no se puede eliminar, mover ni renombrar el archivo de usuarios '%s'", ruta)
+	}
+	return nil
+}
+
+// Libera el inodo con sus bloques y, si es una carpeta, todo su contenido
+func (fs *sistemaArchivos) liberarArbol(indice int32) error {
+	if err := protegerUsuarios(indice, rutaUsuarios); err != nil {
+		return err
+	}
+	inodo, err := fs.leerInodo(indice)
+	if err != nil {
+		return err
+	}
+	if inodo.Type == DirType {
+		entradas, err := fs.entradasCarpeta(inodo)
+		if err != nil {
+			return err
+		}
+		for _, entrada := range entradas {
+			if entrada.Nombre == "." || entrada.Nombre == ".." {
+				continue
+			}
+			if err := fs.liberarArbol(entrada.Inodo); err != nil {
+				return err
+			}
+		}
+	}
+	if err := fs.liberarBloques(inodo); err != nil {
+		return err
+	}
+	return fs.liberarInodo(indice)
+}
+
+// ---------------------------------------RENAME--------------------------------------
+// Analiza el comando rename: -path del archivo o carpeta y -name con el nombre nuevo
+func parseRenameCommand(command string) (path, name string, err error) {
+	parametros, err := leerParametros(command, "path", "name")
+	if err != nil {
+		return "", "", err
+	}
+	path, name = parametros["path"], parametros["name"]
+	if path == "" {
+		return "", "", fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("el parámetro -name es obligatorio")
+	}
+	return path, name, nil
+}
+
+// Comando rename: cambia el nombre del archivo o carpeta
+func renombrarArchivo(path, name string) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("rename", path, fmt.Sprintf("-name=\"%s\"", name), func() error {
+			return fs.renombrar(path, name)
+		})
+	})
+}
+
+// Cambia el nombre de la entrada de la ruta en su carpeta padre
+func (fs *sistemaArchivos) renombrar(ruta, nuevo string) error {
+	if err := validarNombre(nuevo); err != nil {
+		return err
+	}
+	rutaPadre, nombre, err := separarRuta(ruta)
+	if err != nil {
+		return err
+	}
+	indicePadre, padre, err := fs.resolverRuta(rutaPadre)
+	if err != nil {
+		return err
+	}
+	if padre.Type != DirType {
+		return fmt.Errorf("'%s' no es una carpeta", rutaPadre)
+	}
+	if err := fs.autorizar(padre, Exec, rutaPadre); err != nil {
+		return err
+	}
+	entrada, err := fs.buscarEntrada(padre, nombre)
+	if err != nil {
+		return err
+	}
+	if entrada == nil {
+		return fmt.Errorf("no existe '%s'", ruta)
+	}
+	if err := protegerUsuarios(entrada.Inodo, ruta); err != nil {
+		return err
+	}
+	inodo, err := fs.leerInodo(entrada.Inodo)
+	if err != nil {
+		return err
+	}
+	if err := fs.autorizar(inodo, Write, ruta); err != nil {
+		return err
+	}
+	existente, err := fs.buscarEnCarpeta(padre, nuevo)
+	if err != nil {
+		return err
+	}
+	if existente != -1 {
+		return fmt.Errorf("ya existe '%s'", path.Join(rutaPadre, nuevo))
+	}
+
+	contenido := BlockContent{Inode: entrada.Inodo}
+	copy(contenido.Name[:], nuevo)
+	if err := fs.escribirEntrada(entrada, contenido); err != nil {
+		return err
+	}
+	padre.MTIME = fechaActual()
+	return fs.escribirInodo(indicePadre, padre)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+// users.txt no se puede eliminar, mover ni renombrar, ni eliminarse junto con una
+// carpeta que lo contenga
+func TestUsuariosProtegido(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	if err := crearCarpeta("/docs", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/docs/a.txt", 10, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := eliminarArchivo("/users.txt"); err == nil {
+		t.Error("se eliminó /users.txt")
+	}
+	if err := moverArchivo("/users.txt", "/docs"); err == nil {
+		t.Error("se movió /users.txt")
+	}
+	if err := renombrarArchivo("/users.txt", "otro.txt"); err == nil {
+		t.Error("se renombró /users.txt")
+	}
+	if !existeRuta(t, "/users.txt") || existeRuta(t, "/docs/users.txt") {
+		t.Fatal("users.txt cambió de ubicación")
+	}
+
+	// Una carpeta con una entrada que apunta a users.txt no se elimina
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		indice, carpeta, err := fs.resolverRuta("/docs")
+		if err != nil {
+			return err
+		}
+		return fs.agregarEntrada(indice, carpeta, "u.txt", inodoUsuarios)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inodos, bloques int32
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		inodos, bloques = contarLibresBitmaps(t, fs)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := eliminarArchivo("/docs"); err == nil {
+		t.Fatal("se eliminó una carpeta que contiene users.txt")
+	}
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		if i, b := contarLibresBitmaps(t, fs); i != inodos || b != bloques {
+			t.Error("remove rechazado liberó inodos o bloques")
+		}
+		if err := fs.liberarArbol(inodoUsuarios); err == nil {
+			t.Error("liberarArbol liberó users.txt")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contenido, err := leerArchivos([]string{"/users.txt"}); err != nil || contenido != "1,G,root\n1,U,root,root,123\n" {
+		t.Fatalf("users.txt después de los intentos: %q, %v", contenido, err)
+	}
+}
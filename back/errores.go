@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// Error con un código fijo que identifica la causa, para que el frontend y los
+// scripts de prueba no dependan del texto del mensaje.
+type errorCodigo struct {
+	Codigo  string
+	Mensaje string
+}
+
+func (e *errorCodigo) Error() string {
+	return fmt.Sprintf("[%s] %s", e.Codigo, e.Mensaje)
+}
+
+func nuevoErrorCodigo(codigo, mensaje string) *errorCodigo {
+	return &errorCodigo{Codigo: codigo, Mensaje: mensaje}
+}
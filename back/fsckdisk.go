@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Problemas estructurales que informa fsckdisk además de los que fdisk rechaza al
+// crear particiones (ErrNombreDuplicado, ErrExtendidaExistente, ErrTipoParticion,
+// ErrTraslape)
+var (
+	ErrTamanoDisco      = nuevoErrorCodigo("MBR_TAMANO_DISCO", "el tamaño indicado en el MBR no coincide con el del disco")
+	ErrEntradaResidual  = nuevoErrorCodigo("MBR_ENTRADA_RESIDUAL", "entrada libre de la tabla de particiones con datos residuales")
+	ErrTamanoParticion  = nuevoErrorCodigo("PART_TAMANO_INVALIDO", "la partición tiene un tamaño inválido")
+	ErrDentroDelMBR     = nuevoErrorCodigo("PART_DENTRO_DEL_MBR", "la partición inicia dentro del MBR")
+	ErrFueraDelDisco    = nuevoErrorCodigo("PART_FUERA_DEL_DISCO", "la partición termina fuera del disco")
+	ErrFueraDeExtendida = nuevoErrorCodigo("PART_FUERA_DE_EXTENDIDA", "la partición lógica termina fuera de la extendida")
+	ErrSinNombre        = nuevoErrorCodigo("PART_SIN_NOMBRE", "la partición no tiene nombre")
+	ErrCadenaEBRRota    = nuevoErrorCodigo("EBR_CADENA_ROTA", "la cadena de EBRs apunta fuera de la partición extendida")
+	ErrCicloEBR         = nuevoErrorCodigo("EBR_CICLO", "la cadena de EBRs tiene un ciclo")
+	ErrEBRVacio         = nuevoErrorCodigo("EBR_VACIO", "EBR vacío enlazado en la cadena")
+	ErrInicioEBR        = nuevoErrorCodigo("EBR_INICIO", "el EBR no se encuentra donde indica su inicio")
+	ErrTerminadorEBR    = nuevoErrorCodigo("EBR_TERMINADOR", "la cadena de EBRs termina con 0 en lugar de -1")
+)
+
+// Problema estructural encontrado por fsckdisk
+type problemaDisco struct {
+	Offset      int64        // Byte del disco donde se encontró el problema
+	Causa       *errorCodigo // Código del problema
+	Descripcion string       // Descripción del problema
+	Reparado    bool         // Indica si el problema fue reparado
+}
+
+func (p problemaDisco) String() string {
+	if p.Reparado {
+		return fmt.Sprintf("Offset %d: [%s] %s [reparado]", p.Offset, p.Causa.Codigo, p.Descripcion)
+	}
+	return fmt.Sprintf("Offset %d: [%s] %s", p.Offset, p.Causa.Codigo, p.Descripcion)
+}
+
+// ------------------------------------FSCKDISK-DISCOS--------------------------------
+// Analiza el comando fsckdisk y extrae la ruta y la opción de reparación
+func parseFsckdiskCommand(command2 string) (path string, fix bool, err error) {
+	parts := strings.Fields(strings.ToLower(command2))
+	cleanedCommand := strings.SplitN(command2, "#", 2)[0]
+	cleanedCommand = strings.TrimSpace(cleanedCommand)
+	cleanedCommand = strings.ToLower(cleanedCommand)
+
+	for _, part := range parts {
+		if strings.HasPrefix(part, "-path=") {
+			path = strings.TrimPrefix(part, "-path=")
+			// Manejo de comillas alrededor de la ruta
+			if len(path) > 0 && path[0] == '"' && path[len(path)-1] == '"' {
+				path = path[1 : len(path)-1]
+			} else {
+				re := regexp.MustCompile(`-path="([^"]+)"`)
+				matches := re.FindStringSubmatch(cleanedCommand)
+				if len(matches) > 1 {
+					path = matches[1]
+				}
+			}
+		} else if part == "-fix" {
+			fix = true
+		} else if strings.HasPrefix(part, "-") {
+			err = fmt.Errorf("parámetro inválido: %s", part)
+			return
+		}
+	}
+
+	if path == "" {
+		err = fmt.Errorf("ruta no especificada en el comando fsckdisk")
+	}
+	return
+}
+
+// Offset dentro del disco de la entrada i de la tabla de particiones del MBR, que
+// termina donde termina el MBR de los discos anteriores a MbrAlineacion
+func offsetEntradaMBR(i int) int64 {
+	var mbr MBR
+	return tamanoMBRLegado - int64(len(mbr.Partitions)-i)*int64(binary.Size(Partition1{}))
+}
+
+// Verifica la estructura del MBR y de la cadena de EBRs del disco. Si reparar es
+// verdadero corrige los terminadores de la cadena y limpia las entradas colgantes.
+func verificarDisco(path string, reparar bool) ([]problemaDisco, error) {
+	flag := os.O_RDONLY
+	if reparar {
+		flag = os.O_RDWR
+	}
+	file, cerrar, err := motor.abrirDisco(path, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir el archivo del disco: %v", err)
+	}
+	defer cerrar()
+
+	mbr, err := leerMBR(file)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer el MBR: %v", err)
+	}
+
+	var problemas []problemaDisco
+	reportar := func(offset int64, reparado bool, causa *errorCodigo, format string, args ...interface{}) {
+		problemas = append(problemas, problemaDisco{Offset: offset, Causa: causa, Descripcion: fmt.Sprintf(format, args...), Reparado: reparado})
+	}
+
+	// Tamaño del disco
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la información del disco: %v", err)
+	}
+	if mbr.MbrTamano != info.Size() {
+		reportar(0, false, ErrTamanoDisco, "el MBR indica %d bytes pero el disco mide %d bytes", mbr.MbrTamano, info.Size())
+	}
+
+	sizeMBR := tamanoMBR(&mbr)
+	mbrModificado := false
+	nombres := make(map[string]int64) // nombre -> offset donde se definió
+	extendidas := 0
+
+	for i := range mbr.Partitions {
+		part := &mbr.Partitions[i]
+		offset := offsetEntradaMBR(i)
+		nombre := strings.Trim(string(part.PartName[:]), "\x00")
+
+		if part.PartStatus == 0 {
+			// Entrada libre con datos residuales (por ejemplo, después de fdisk -delete=fast)
+			if *part != (Partition1{}) {
+				if reparar {
+					*part = Partition1{}
+					mbrModificado = true
+				}
+				reportar(offset, reparar, ErrEntradaResidual, "entrada %d libre con datos residuales ('%s')", i+1, nombre)
+			}
+			continue
+		}
+
+		if part.PartType != 'p' && part.PartType != 'e' {
+			reportar(offset, false, ErrTipoParticion, "partición '%s' con tipo inválido '%c'", nombre, part.PartType)
+		}
+		if part.PartType == 'e' {
+			extendidas++
+		}
+		if part.PartS <= 0 {
+			reportar(offset, false, ErrTamanoParticion, "partición '%s' con tamaño inválido %d", nombre, part.PartS)
+		}
+		if part.PartStart < sizeMBR {
+			reportar(offset, false, ErrDentroDelMBR, "partición '%s' inicia en %d, dentro del MBR (%d bytes)", nombre, part.PartStart, sizeMBR)
+		}
+		if part.PartStart+part.PartS > mbr.MbrTamano {
+			reportar(offset, false, ErrFueraDelDisco, "partición '%s' termina en %d, fuera del disco (%d bytes)", nombre, part.PartStart+part.PartS, mbr.MbrTamano)
+		}
+		if nombre == "" {
+			reportar(offset, false, ErrSinNombre, "partición %d sin nombre", i+1)
+		} else if previo, existe := nombres[nombre]; existe {
+			reportar(offset, false, ErrNombreDuplicado, "nombre '%s' duplicado (también en el offset %d)", nombre, previo)
+		} else {
+			nombres[nombre] = offset
+		}
+
+		// Traslapes con las particiones anteriores
+		for j := 0; j < i; j++ {
+			otra := mbr.Partitions[j]
+			if otra.PartStatus == 0 {
+				continue
+			}
+			if part.PartStart < otra.PartStart+otra.PartS && otra.PartStart < part.PartStart+part.PartS {
+				reportar(offset, false, ErrTraslape, "partición '%s' [%d, %d) se traslapa con '%s' [%d, %d)",
+					nombre, part.PartStart, part.PartStart+part.PartS,
+					strings.Trim(string(otra.PartName[:]), "\x00"), otra.PartStart, otra.PartStart+otra.PartS)
+			}
+		}
+	}
+
+	if extendidas > 1 {
+		reportar(offsetEntradaMBR(0), false, ErrExtendidaExistente, "el disco tiene %d particiones extendidas, solo se permite una", extendidas)
+	}
+
+	// Verificar la cadena de EBRs de cada partición extendida
+	for _, part := range mbr.Partitions {
+		if part.PartStatus == 0 || part.PartType != 'e' || part.PartS <= 0 {
+			continue
+		}
+		if part.PartStart < sizeMBR || part.PartStart+part.PartS > mbr.MbrTamano {
+			// Ya reportada, no se puede recorrer con seguridad
+			continue
+		}
+		problemasEBR, err := verificarCadenaEBR(file, part, nombres, reparar)
+		if err != nil {
+			return problemas, err
+		}
+		problemas = append(problemas, problemasEBR...)
+	}
+
+	if mbrModificado {
+		if err := escribirMBR(file, &mbr); err != nil {
+			return problemas, fmt.Errorf("error al escribir el MBR reparado: %v", err)
+		}
+	}
+
+	return problemas, nil
+}
+
+// Recorre la cadena de EBRs de la partición extendida verificando que sea acíclica,
+// que permanezca dentro de la extendida y que termine en -1.
+func verificarCadenaEBR(file *os.File, extendida Partition1, nombres map[string]int64, reparar bool) ([]problemaDisco, error) {
+	var problemas []problemaDisco
+	reportar := func(offset int64, reparado bool, causa *errorCodigo, format string, args ...interface{}) {
+		problemas = append(problemas, problemaDisco{Offset: offset, Causa: causa, Descripcion: fmt.Sprintf(format, args...), Reparado: reparado})
+	}
+
+	sizeEBR := int64(binary.Size(EBR{}))
+	finExtendida := extendida.PartStart + extendida.PartS
+	visitados := make(map[int64]bool)
+
+	// Cortar la cadena en el EBR anterior, dejándolo como el último
+	var anterior *EBR
+	var posAnterior int64
+	terminarEnAnterior := func() error {
+		if anterior == nil || !reparar {
+			return nil
+		}
+		anterior.Next = -1
+		return writeEBR(file, anterior, posAnterior)
+	}
+
+	pos := extendida.PartStart
+	var finAnterior int64 = extendida.PartStart
+	for {
+		if pos < extendida.PartStart || pos+sizeEBR > finExtendida {
+			if err := terminarEnAnterior(); err != nil {
+				return problemas, err
+			}
+			reportar(posAnterior, reparar && anterior != nil, ErrCadenaEBRRota, "el EBR apunta a %d, fuera de la partición extendida [%d, %d)", pos, extendida.PartStart, finExtendida)
+			break
+		}
+		if visitados[pos] {
+			if err := terminarEnAnterior(); err != nil {
+				return problemas, err
+			}
+			reportar(posAnterior, reparar && anterior != nil, ErrCicloEBR, "ciclo en la cadena de EBRs: el EBR apunta de nuevo a %d", pos)
+			break
+		}
+		visitados[pos] = true
+
+		ebr, err := readEBR(file, pos)
+		if err != nil {
+			return problemas, err
+		}
+
+		if ebr.Size <= 0 {
+			if anterior == nil {
+				// Extendida sin particiones lógicas
+				break
+			}
+			// EBR vacío en medio de la cadena: saltarlo
+			if reparar {
+				anterior.Next = ebr.Next
+				if esFinCadenaEBR(anterior.Next) {
+					anterior.Next = -1
+				}
+				if err := writeEBR(file, anterior, posAnterior); err != nil {
+					return problemas, err
+				}
+			}
+			reportar(pos, reparar, ErrEBRVacio, "EBR vacío enlazado en la cadena")
+			if esFinCadenaEBR(ebr.Next) {
+				break
+			}
+			pos = ebr.Next
+			continue
+		}
+
+		nombre := strings.Trim(string(ebr.Name[:]), "\x00")
+		if ebr.Start != pos {
+			reportar(pos, false, ErrInicioEBR, "EBR '%s' indica inicio %d pero se encuentra en %d", nombre, ebr.Start, pos)
+		}
+		if ebr.Start < finAnterior {
+			reportar(pos, false, ErrTraslape, "partición lógica '%s' se traslapa con la anterior (termina en %d)", nombre, finAnterior)
+		}
+		if ebr.Start+ebr.Size > finExtendida {
+			reportar(pos, false, ErrFueraDeExtendida, "partición lógica '%s' termina en %d, fuera de la extendida (%d)", nombre, ebr.Start+ebr.Size, finExtendida)
+		}
+		if nombre == "" {
+			reportar(pos, false, ErrSinNombre, "partición lógica sin nombre")
+		} else if previo, existe := nombres[nombre]; existe {
+			reportar(pos, false, ErrNombreDuplicado, "nombre '%s' duplicado (también en el offset %d)", nombre, previo)
+		} else {
+			nombres[nombre] = pos
+		}
+
+		if ebr.Next == 0 {
+			// Terminador antiguo escrito por crearEBR
+			if reparar {
+				ebr.Next = -1
+				if err := writeEBR(file, ebr, pos); err != nil {
+					return problemas, err
+				}
+			}
+			reportar(pos, reparar, ErrTerminadorEBR, "EBR '%s' termina la cadena con 0 en lugar de -1", nombre)
+			break
+		}
+		if ebr.Next == -1 {
+			break
+		}
+
+		anterior = ebr
+		posAnterior = pos
+		finAnterior = ebr.Start + ebr.Size
+		pos = ebr.Next
+	}
+
+	return problemas, nil
+}
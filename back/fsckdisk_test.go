@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Crea un disco con dos primarias y una extendida con dos lógicas y devuelve su
+// ruta y los EBRs de la cadena
+func prepararDiscoFsck(t *testing.T) (string, []EBR) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	crearDisco(path, 128*1024, 'F', 4096)
+	for _, particion := range []struct {
+		nombre, tipo string
+		tamano       int64
+	}{{"p1", "p", 8 * 1024}, {"p2", "p", 8 * 1024}, {"ext", "e", 32 * 1024}} {
+		if err := crearParticion(path, particion.tamano, particion.nombre, particion.tipo, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, nombre := range []string{"l1", "l2"} {
+		if err := crearParticionLogica(path, 8*1024, nombre, "F"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	problemas, err := verificarDisco(path, false)
+	if err != nil || len(problemas) != 0 {
+		t.Fatalf("disco recién creado con problemas: %v, %v", problemas, err)
+	}
+	return path, leerEBRsPrueba(t, path)
+}
+
+func leerEBRsPrueba(t *testing.T, path string) []EBR {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leerEBRsDelMBR(file, mbr)
+}
+
+// Cambia el EBR del disco que inicia en start
+func modificarEBR(t *testing.T, path string, start int64, cambiar func(ebr *EBR)) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	ebr, err := readEBR(file, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cambiar(ebr)
+	if err := writeEBR(file, ebr, start); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Verifica que fsckdisk informe los problemas con los códigos esperados y que, con
+// -fix, el disco reparado pase una segunda verificación sin problemas
+func verificarProblemas(t *testing.T, path string, reparable bool, causas ...*errorCodigo) {
+	t.Helper()
+	problemas, err := verificarDisco(path, reparable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encontradas := make(map[*errorCodigo]bool)
+	for _, problema := range problemas {
+		if problema.Causa == nil {
+			t.Errorf("problema sin código: %s", problema.Descripcion)
+			continue
+		}
+		encontradas[problema.Causa] = true
+		if problema.Reparado != reparable {
+			t.Errorf("%s reparado = %t", problema, problema.Reparado)
+		}
+	}
+	for _, causa := range causas {
+		if !encontradas[causa] {
+			t.Errorf("no se informó %s: %v", causa.Codigo, problemas)
+		}
+	}
+	if !reparable {
+		return
+	}
+	if problemas, err = verificarDisco(path, false); err != nil || len(problemas) != 0 {
+		t.Fatalf("problemas después de reparar: %v, %v", problemas, err)
+	}
+}
+
+func TestFsckdiskParticionesTraslapadas(t *testing.T) {
+	path, _ := prepararDiscoFsck(t)
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr.Partitions[1].PartStart = mbr.Partitions[0].PartStart + mbr.Partitions[0].PartS/2
+	if err := escribirMBR(file, &mbr); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	verificarProblemas(t, path, false, ErrTraslape)
+}
+
+func TestFsckdiskCadenaEBRRota(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	modificarEBR(t, path, ebrs[0].Start, func(ebr *EBR) { ebr.Next = 1 << 30 })
+	verificarProblemas(t, path, true, ErrCadenaEBRRota)
+	if ebrs := leerEBRsPrueba(t, path); len(ebrs) != 1 || ebrs[0].Next != -1 {
+		t.Errorf("cadena reparada: %+v", ebrs)
+	}
+}
+
+func TestFsckdiskCicloEBR(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	if len(ebrs) != 2 {
+		t.Fatalf("se esperaban dos lógicas, hay %d", len(ebrs))
+	}
+	modificarEBR(t, path, ebrs[1].Start, func(ebr *EBR) { ebr.Next = ebrs[0].Start })
+	verificarProblemas(t, path, true, ErrCicloEBR)
+	if ebrs := leerEBRsPrueba(t, path); len(ebrs) != 2 || ebrs[1].Next != -1 {
+		t.Errorf("cadena reparada: %+v", ebrs)
+	}
+}
+
+// Una cadena terminada con 0 se repara con -1
+func TestFsckdiskTerminadorAntiguo(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	modificarEBR(t, path, ebrs[1].Start, func(ebr *EBR) { ebr.Next = 0 })
+	verificarProblemas(t, path, true, ErrTerminadorEBR)
+	if ebrs := leerEBRsPrueba(t, path); len(ebrs) != 2 || ebrs[1].Next != -1 {
+		t.Errorf("cadena reparada: %+v", ebrs)
+	}
+}
+
+// -fix limpia las entradas libres del MBR con datos residuales y saca de la cadena
+// los EBRs vacíos
+func TestFsckdiskEntradasColgantes(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr.Partitions[3] = Partition1{PartType: 'p', PartStart: 90000, PartS: 1024}
+	copy(mbr.Partitions[3].PartName[:], "borrada")
+	if err := escribirMBR(file, &mbr); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	modificarEBR(t, path, ebrs[1].Start, func(ebr *EBR) { ebr.Size = 0 })
+
+	verificarProblemas(t, path, true, ErrEntradaResidual, ErrEBRVacio)
+	if ebrs := leerEBRsPrueba(t, path); len(ebrs) != 1 || ebrs[0].Next != -1 {
+		t.Errorf("cadena reparada: %+v", ebrs)
+	}
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if mbr, err = leerMBR(file); err != nil {
+		t.Fatal(err)
+	}
+	if mbr.Partitions[3] != (Partition1{}) {
+		t.Errorf("entrada residual después de reparar: %+v", mbr.Partitions[3])
+	}
+}
+
+// Los nombres se comparan entre primarias y lógicas; -fix no los cambia
+func TestFsckdiskNombreDuplicado(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	modificarEBR(t, path, ebrs[1].Start, func(ebr *EBR) {
+		ebr.Name = [16]byte{}
+		copy(ebr.Name[:], "p1")
+	})
+	verificarProblemas(t, path, false, ErrNombreDuplicado)
+	if _, err := verificarDisco(path, true); err != nil {
+		t.Fatal(err)
+	}
+	verificarProblemas(t, path, false, ErrNombreDuplicado)
+}
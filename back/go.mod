@@ -0,0 +1,3 @@
+module mia/back
+
+go 1.21
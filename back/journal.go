@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Entrada del journal de EXT3. El journal ocupa una entrada por inodo y se guarda
+// justo después del SuperBlock; cada operación que modifica el sistema de archivos
+// agrega una entrada con los parámetros necesarios para repetirla.
+type Journal struct {
+	Count     int32     // j_count: Número de la entrada (0 = libre)
+	Operation [10]byte  // i_operation: Comando ejecutado (mkdir, mkfile, ...)
+	Path      [64]byte  // i_path: Ruta sobre la que se ejecutó
+	Content   [100]byte // i_content: Resto de parámetros del comando
+	Date      [19]byte  // i_date: Fecha de la operación
+}
+
+// Operación de las entradas que guardan el contenido de un archivo (mkfile -cont y
+// edit). Siguen a la entrada de la operación, que lleva -size y -datos, y cada una
+// guarda en Content hasta 100 bytes del contenido tal cual.
+const operacionDatos = "datos"
+
+// Error cuando no quedan entradas libres en el journal para la operación
+var ErrJournalLleno = nuevoErrorCodigo("JOURNAL_LLENO", "el journal está lleno")
+
+// Inicio del journal dentro de la partición
+func inicioJournal(inicioParticion int64) int64 {
+	return inicioParticion + int64(binary.Size(SuperBlock{}))
+}
+
+// Agrega una entrada al journal si la partición es EXT3. En EXT2 no hace nada. El
+// journal tiene tantas entradas como inodos; cuando se llena devuelve un error.
+func registrarJournal(file *os.File, superblock *SuperBlock, inicioParticion int64, operacion, path, contenido string) error {
+	if superblock.FilesystemType != 3 {
+		return nil
+	}
+	entrada, err := nuevaEntradaJournal(operacion, path, contenido)
+	if err != nil {
+		return err
+	}
+	libre, err := entradaLibreJournal(file, superblock, inicioParticion)
+	if err != nil {
+		return err
+	}
+	return escribirEntradaJournal(file, inicioParticion, libre, entrada)
+}
+
+// Arma una entrada del journal. Los valores que no caben en los campos de tamaño
+// fijo se rechazan: truncarlos haría que recovery repita otra operación.
+func nuevaEntradaJournal(operacion, path, contenido string) (Journal, error) {
+	entrada := Journal{Date: fechaActual()}
+	contenido = strings.TrimSpace(contenido)
+	if len(operacion) > len(entrada.Operation) {
+		return entrada, fmt.Errorf("la operación '%s' no cabe en el journal (máximo %d caracteres)", operacion, len(entrada.Operation))
+	}
+	if len(path) > len(entrada.Path) {
+		return entrada, fmt.Errorf("la ruta '%s' no cabe en el journal (máximo %d caracteres)", path, len(entrada.Path))
+	}
+	if len(contenido) > len(entrada.Content) {
+		return entrada, fmt.Errorf("los parámetros '%s' no caben en el journal (máximo %d caracteres)", contenido, len(entrada.Content))
+	}
+	copy(entrada.Operation[:], operacion)
+	copy(entrada.Path[:], path)
+	copy(entrada.Content[:], contenido)
+	return entrada, nil
+}
+
+// Entradas "datos" con el contenido de un archivo en partes del tamaño de Content
+func entradasDatosJournal(path string, datos []byte) []Journal {
+	var entradas []Journal
+	tamano := len(Journal{}.Content)
+	for i := 0; i < len(datos); i += tamano {
+		entrada := Journal{Date: fechaActual()}
+		copy(entrada.Operation[:], operacionDatos)
+		copy(entrada.Path[:], path)
+		copy(entrada.Content[:], datos[i:])
+		entradas = append(entradas, entrada)
+	}
+	return entradas
+}
+
+// Índice de la primera entrada libre del journal, o un error si está lleno
+func entradaLibreJournal(file *os.File, superblock *SuperBlock, inicioParticion int64) (int32, error) {
+	if _, err := file.Seek(inicioJournal(inicioParticion), 0); err != nil {
+		return -1, fmt.Errorf("Error al posicionarse en el journal: %v", err)
+	}
+	for i := int32(0); i < superblock.InodesCount; i++ {
+		var entrada Journal
+		if err := binary.Read(file, binary.LittleEndian, &entrada); err != nil {
+			return -1, fmt.Errorf("Error al leer el journal: %v", err)
+		}
+		if entrada.Count == 0 {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w (%d entradas)", ErrJournalLleno, superblock.InodesCount)
+}
+
+// Escribe la entrada en la posición indice del journal
+func escribirEntradaJournal(file *os.File, inicioParticion int64, indice int32, entrada Journal) error {
+	entrada.Count = indice + 1
+	posicion := inicioJournal(inicioParticion) + int64(indice)*int64(binary.Size(Journal{}))
+	if _, err := file.Seek(posicion, 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el journal: %v", err)
+	}
+	if err := binary.Write(file, binary.LittleEndian, &entrada); err != nil {
+		return fmt.Errorf("Error al escribir el journal: %v", err)
+	}
+	return nil
+}
+
+// Lee las entradas ocupadas del journal en el orden en que se registraron
+func leerJournal(file *os.File, superblock *SuperBlock, inicioParticion int64) ([]Journal, error) {
+	var entradas []Journal
+	if _, err := file.Seek(inicioJournal(inicioParticion), 0); err != nil {
+		return nil, fmt.Errorf("Error al posicionarse en el journal: %v", err)
+	}
+	for i := int32(0); i < superblock.InodesCount; i++ {
+		var entrada Journal
+		if err := binary.Read(file, binary.LittleEndian, &entrada); err != nil {
+			return nil, fmt.Errorf("Error al leer el journal: %v", err)
+		}
+		if entrada.Count == 0 {
+			break
+		}
+		entradas = append(entradas, entrada)
+	}
+	return entradas, nil
+}
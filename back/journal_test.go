@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Entradas del journal de la partición de prueba como "operación ruta parámetros"
+func entradasJournalPrueba(t *testing.T) []string {
+	t.Helper()
+	var entradas []string
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		journal, err := leerJournal(fs.file, fs.superblock, fs.inicio)
+		if err != nil {
+			return err
+		}
+		for _, entrada := range journal {
+			entradas = append(entradas, strings.Join([]string{
+				strings.Trim(string(entrada.Operation[:]), "\x00"),
+				strings.Trim(string(entrada.Path[:]), "\x00"),
+				strings.Trim(string(entrada.Content[:]), "\x00"),
+			}, " "))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entradas
+}
+
+// Verifica si la ruta existe en la partición de prueba
+func existeRuta(t *testing.T, ruta string) bool {
+	t.Helper()
+	existe := false
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		_, _, err := fs.resolverRuta(ruta)
+		existe = err == nil
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return existe
+}
+
+func TestJournalRegistraParametrosDeMkfs(t *testing.T) {
+	prepararSistemaArchivos(t, 64*1024, "ext3")
+	entradas := entradasJournalPrueba(t)
+	if len(entradas) != 1 || entradas[0] != "mkfs / -type=fast -fs=3fs" {
+		t.Fatalf("journal después de mkfs: %q", entradas)
+	}
+}
+
+func TestJournalRegistraElUsuario(t *testing.T) {
+	prepararSistemaArchivos(t, 64*1024, "ext3")
+	if err := crearCarpeta("/docs", false); err != nil {
+		t.Fatal(err)
+	}
+	entradas := entradasJournalPrueba(t)
+	if len(entradas) != 2 || entradas[1] != "mkdir /docs -uid=1 -gid=1" {
+		t.Fatalf("journal después de mkdir: %q", entradas)
+	}
+}
+
+// Una operación cuya entrada no cabe en el journal se rechaza sin aplicarse
+func TestJournalRechazaEntradasQueNoCaben(t *testing.T) {
+	prepararSistemaArchivos(t, 256*1024, "ext3")
+
+	// Ruta de 78 caracteres, el campo Path tiene 64
+	ruta := "/" + strings.Repeat("carpetalarga/", 6)
+	ruta = strings.TrimSuffix(ruta, "/")
+	if err := crearCarpeta(ruta, true); err == nil {
+		t.Error("se creó una carpeta cuya ruta no cabe en el journal")
+	}
+	if existeRuta(t, "/carpetalarga") {
+		t.Error("mkdir rechazado creó carpetas")
+	}
+
+	// Parámetros de más de 100 caracteres: un -destino largo
+	destino := "/" + strings.Repeat("d", 100)
+	err := copiarArchivo("/users.txt", destino)
+	if err == nil || !strings.Contains(err.Error(), "no caben en el journal") {
+		t.Errorf("copy con parámetros que no caben en el journal: %v", err)
+	}
+
+	if entradas := entradasJournalPrueba(t); len(entradas) != 1 {
+		t.Fatalf("las operaciones rechazadas quedaron en el journal: %q", entradas)
+	}
+}
+
+// El journal guarda los nombres y los campos de users.txt entre comillas, así que
+// un valor con comillas se rechaza antes de registrarse
+func TestJournalRechazaComillas(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext3")
+	if err := crearCarpeta("/docs", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := crearCarpeta(`/a"b`, false); err == nil {
+		t.Error(`mkdir aceptó un nombre con comillas`)
+	}
+	if err := renombrarArchivo("/docs", `d"s`); err == nil {
+		t.Error("rename aceptó un nombre con comillas")
+	}
+	if err := copiarArchivo("/users.txt", `/docs/x" -r`); err == nil {
+		t.Error("copy aceptó un destino con comillas")
+	}
+	if err := crearGrupo(`g"1`); err == nil {
+		t.Error("mkgrp aceptó un nombre con comillas")
+	}
+	if err := crearUsuario("u1", `p" -grp="root`, "root"); err == nil {
+		t.Error("mkusr aceptó una contraseña con comillas")
+	}
+	if err := cambiarGrupo("root", `root"`); err == nil {
+		t.Error("chgrp aceptó un grupo con comillas")
+	}
+
+	if entradas := entradasJournalPrueba(t); len(entradas) != 2 {
+		t.Fatalf("las operaciones rechazadas quedaron en el journal: %q", entradas)
+	}
+	if contenido, err := leerArchivos([]string{"/users.txt"}); err != nil || contenido != "1,G,root\n1,U,root,root,123\n" {
+		t.Fatalf("users.txt después de los intentos: %q, %v", contenido, err)
+	}
+}
+
+func TestJournalLleno(t *testing.T) {
+	prepararSistemaArchivos(t, 64*1024, "ext3")
+	var total int32
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		total = fs.superblock.InodesCount
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mkfs ocupa la primera entrada
+	for i := int32(1); i < total; i++ {
+		if err := cambiarPermisos("/users.txt", "664", false); err != nil {
+			t.Fatalf("chmod %d: %v", i, err)
+		}
+	}
+	err = cambiarPermisos("/users.txt", "600", false)
+	if err == nil || !strings.Contains(err.Error(), "journal está lleno") {
+		t.Fatalf("chmod con el journal lleno: %v", err)
+	}
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		archivo, err := fs.leerInodo(inodoUsuarios)
+		if err != nil {
+			return err
+		}
+		if string(archivo.Perms[:]) != "664" {
+			t.Errorf("chmod rechazado cambió los permisos a %s", archivo.Perms)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mkfile -cont y edit guardan el contenido en entradas "datos" después de la
+// entrada de la operación
+func TestJournalGuardaElContenidoDeArchivosDelServidor(t *testing.T) {
+	prepararSistemaArchivos(t, 64*1024, "ext3")
+	cont := filepath.Join(t.TempDir(), "cont.txt")
+	contenido := strings.Repeat("0123456789", 15) + "fin"
+	if err := os.WriteFile(cont, []byte(contenido), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/a.txt", 0, cont, false); err != nil {
+		t.Fatal(err)
+	}
+	entradas := entradasJournalPrueba(t)
+	esperadas := []string{
+		"mkfs / -type=fast -fs=3fs",
+		"mkfile /a.txt -size=153 -datos -uid=1 -gid=1",
+		"datos /a.txt " + contenido[:100],
+		"datos /a.txt " + contenido[100:],
+	}
+	if strings.Join(entradas, "\n") != strings.Join(esperadas, "\n") {
+		t.Fatalf("journal después de mkfile -cont: %q", entradas)
+	}
+}
+
+// Si el contenido no cabe en las entradas libres del journal, la operación se
+// rechaza con el código JOURNAL_LLENO sin aplicarse
+func TestJournalRechazaContenidoQueNoCabe(t *testing.T) {
+	prepararSistemaArchivos(t, 64*1024, "ext3")
+	var total int32
+	err := consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		total = fs.superblock.InodesCount
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cont := filepath.Join(t.TempDir(), "cont.txt")
+	if err := os.WriteFile(cont, make([]byte, int(total)*100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/a.txt", 0, cont, false); !errors.Is(err, ErrJournalLleno) {
+		t.Fatalf("mkfile con contenido que no cabe en el journal: %v", err)
+	}
+	if existeRuta(t, "/a.txt") {
+		t.Error("mkfile rechazado creó el archivo")
+	}
+	if entradas := entradasJournalPrueba(t); len(entradas) != 1 {
+		t.Errorf("la operación rechazada quedó en el journal: %d entradas", len(entradas))
+	}
+}
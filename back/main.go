@@ -1,14 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
-	"sync"
 )
 
 type Response struct {
@@ -18,8 +16,6 @@ type Response struct {
 	Error       string      `json:"error,omitempty"`
 }
 
-var disks []Disk
-var mutex sync.Mutex
 var partitions []Partition
 
 func messageHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,8 +46,6 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 			PartResoult []Partition `json:"part_resoult"`
 			Error       string      `json:"error,omitempty"`
 		}{}
-		var primaryCount int = 0
-		var extendedCount int = 0
 
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			response.Error = "Invalid request payload"
@@ -76,7 +70,7 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 				continue // Continuar al siguiente comando
 			}
 			//fmt.Println("Procesando comando:", cmd)
-			size, unit, path, partitionType, fit, name, id, _, _, user, pass, _, _, _, _, err := parseCommand(cmd)
+			size, unit, path, partitionType, fit, name, _, _, id, _, _, user, pass, _, _, err := parseCommand(cmd)
 			if err != nil {
 				response.Error = fmt.Sprintf("Error al procesar el comando: %s", err)
 				response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
@@ -96,9 +90,7 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 				response.Message = append(response.Message, fmt.Sprintf("Disco creado: Size=%d, Unit=%s, Path=%s, Fit=%s", size, unit, path, fit))
 
 				//MANDAR FRONTEND Discos
-				mutex.Lock()
-				disks = append(disks, disk)
-				mutex.Unlock()
+				motor.agregarDisco(disk)
 				fmt.Println("Disco creado:", disk.Path)
 
 				// Agregar el nuevo disco a la lista `response.DiskResoult`
@@ -112,30 +104,15 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 
-				// Encontrar el disco en la lista interna 'disks'
-				found := false
-				mutex.Lock()
-				for i, _ := range disks {
-					// Eliminar el archivo del disco del sistema
-					err := deleteDisk(path)
-					if err != nil {
-						response.Message = append(response.Message, fmt.Sprintf("Error al eliminar el disco: %s", err.Error()))
-					} else {
-						// Eliminar el disco de la lista 'disks'
-						disks = append(disks[:i], disks[i+1:]...)
-						found = true
-						response.Message = append(response.Message, fmt.Sprintf("Disco eliminado: Path=%s", path))
-					}
-					break
-				}
-				mutex.Unlock()
-
-				if !found {
-					response.Message = append(response.Message, fmt.Sprintf("Error: No se encontró un disco con la ruta %s", path))
+				// Eliminar el archivo del disco, esté o no en la lista de discos
+				if err := eliminarDisco(path); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al eliminar el disco: %s", err.Error()))
+				} else {
+					response.Message = append(response.Message, fmt.Sprintf("Disco eliminado: Path=%s", path))
 				}
 
 				// Actualizar la lista de discos en la respuesta
-				response.DiskResoult = disks
+				response.DiskResoult = motor.listarDiscos()
 			} else if strings.HasPrefix(cmd, "fdisk") {
 				var delete string = ""
 				var add string = ""
@@ -157,57 +134,8 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 					response.Message = append(response.Message, "Se agregaron: ", add)
 
 				} else {
-					if partitionType == "p" {
-						if primaryCount >= 4 {
-							response.Message = append(response.Message, "Error: No se pueden crear más de 4 particiones primarias.")
-							//fmt.Println("Error: No se pueden crear más de 4 particiones primarias.")
-							primaryCount = 0
-							continue
-						}
-						//fmt.Println("Unidad de la particion: ", unit)
-						//fmt.Println("tamaño particion: ", size)
-
-						// Intentar crear la partición primaria
-						var size1 int64
-						if unit == "k" { // convertir a bytes
-							size1 = int64(size) * 1024
-						} else if unit == "m" { // convertir a bytes
-							size1 = int64(size) * 1024 * 1024
-						} else if unit == "b" {
-							size1 = int64(size) * 1
-						}
-
-						partition := Partition1{
-							PartStatus: '0',              // Marcar la partición como activa
-							PartType:   partitionType[0], // Tipo de partición ('p' para primaria, 'e' para extendida)
-							PartFit:    fit[0],           // Ajuste de la partición
-							PartS:      int64(size),
-						}
-						copy(partition.PartName[:], name)
-
-						//fmt.Println("tamaño particion2: ", size1)
-						err := crearParticion(path, int64(size1), name, partitionType)
-						if err != nil {
-							response.Message = append(response.Message, fmt.Sprintf("Error al crear la partición: %s", err.Error()))
-							//fmt.Println("Error al crear partición:", err)
-							continue
-						}
-
-						// Incrementar primaryCount solo si no hubo errores
-						primaryCount++
-						response.Message = append(response.Message, fmt.Sprintf("Partición creada: Size=%d, Unit=%s, Path=%s, Type=%s, Fit=%s, Name=%s", size, unit, path, partitionType, fit, name))
-
-						//fmt.Printf("Partición creada: Size=%d, Unit=%s, Path=%s, Type=%s, Fit=%s, Name=%s\n", size, unit, path, partitionType, fit, name)
-						//fmt.Println("Particiones Primarias creadas: ", primaryCount)
-
-					} else if partitionType == "e" {
-						if extendedCount >= 1 {
-							response.Message = append(response.Message, "Error: Ya existe una partición extendida en el disco.")
-							//fmt.Println("Error: Ya existe una partición extendida en el disco.")
-							extendedCount = 0
-							continue
-						}
-
+					if partitionType == "p" || partitionType == "e" {
+						// Convertir el tamaño a bytes
 						var size1 int64
 						if unit == "k" { // convertir a bytes
 							size1 = int64(size) * 1024
@@ -217,29 +145,17 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 							size1 = int64(size) * 1
 						}
 
-						partition := Partition1{
-							PartStatus: '0',              // Marcar la partición como activa
-							PartType:   partitionType[0], // Tipo de partición ('p' para primaria, 'e' para extendida)
-							PartFit:    fit[0],           // Ajuste de la partición
-							PartS:      int64(size),
-						}
-						copy(partition.PartName[:], name)
-
-						// Intentar crear la partición extendida
-						err := crearParticion(path, int64(size1), name, partitionType)
+						// Los límites de primarias y extendida se validan contra el MBR del disco
+						err := crearParticion(path, int64(size1), name, partitionType, fit)
 						if err != nil {
 							response.Message = append(response.Message, fmt.Sprintf("Error al crear la partición: %s", err.Error()))
 							//fmt.Println("Error al crear partición:", err)
 							continue
 						}
 
-						// Incrementar extendedCount solo si no hubo errores
-						extendedCount++
 						response.Message = append(response.Message, fmt.Sprintf("Partición creada: Size=%d, Unit=%s, Path=%s, Type=%s, Fit=%s, Name=%s", size, unit, path, partitionType, fit, name))
 
 						//fmt.Printf("Partición creada: Size=%d, Unit=%s, Path=%s, Type=%s, Fit=%s, Name=%s\n", size, unit, path, partitionType, fit, name)
-						//fmt.Print("Particiones Extendidas creadas: ", extendedCount)
-
 					} else if partitionType == "l" {
 						var size1 int64
 						if unit == "k" { // convertir a bytes
@@ -266,67 +182,93 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 						fmt.Println("Error: Tipo de partición no válido:", partitionType)
 					}
 
-					file, err := os.OpenFile(path, os.O_RDWR, 0666)
+					file, cerrar, err := motor.abrirDisco(path, os.O_RDONLY, 0)
 					if err != nil {
 						response.Message = append(response.Message, fmt.Sprintf("Error al abrir el archivo: %s", err.Error()))
 						return
 					}
-					defer file.Close()
 
-					var mbr MBR
-					if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+					_, err = leerMBR(file)
+					cerrar()
+					if err != nil {
 						response.Message = append(response.Message, fmt.Sprintf("Error al leer el MBR: %s", err.Error()))
 						return
 					}
 					//imprimirPartitions(file, &mbr)
 				}
+			} else if strings.HasPrefix(cmd, "fsckdisk") {
+				path, fix, err := parseFsckdiskCommand(cmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				problemas, err := verificarDisco(path, fix)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al verificar el disco: %s", err.Error()))
+					continue
+				}
+				if len(problemas) == 0 {
+					response.Message = append(response.Message, fmt.Sprintf("Disco verificado sin problemas: Path=%s", path))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Problemas encontrados en el disco %s: %d", path, len(problemas)))
+				for _, problema := range problemas {
+					response.Message = append(response.Message, problema.String())
+				}
+			} else if strings.HasPrefix(cmd, "mounted") {
+				response.Message = append(response.Message, printMountedPartitions()...)
 			} else if strings.HasPrefix(cmd, "mount") {
 				isMounted, _ := isPartitionMounted(path, name)
 
 				// Abrir el archivo del disco
-				file, err := os.OpenFile(path, os.O_RDWR, 0644)
+				file, cerrar, err := motor.abrirDisco(path, os.O_RDONLY, 0)
 				if err != nil {
 					response.Message = append(response.Message, fmt.Sprintf("Error al abrir el archivo: %s", err.Error()))
 					return
 				}
-				defer file.Close()
 				// Buscar la partición por nombre dentro del MBR
-				var mbr MBR
-				if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+				mbr, err := leerMBR(file)
+				if err != nil {
+					cerrar()
 					response.Message = append(response.Message, fmt.Sprintf("Error al leer el MBR: %s", err.Error()))
 					return
 				}
 
-				found := false
-				for i := 0; i < len(mbr.Partitions); i++ {
-					part := &mbr.Partitions[i]
-					if strings.Trim(string(part.PartName[:]), "\x00") == name && part.PartType == 'p' {
-						found = true
-						break
-					}
-				}
+				// Se pueden montar primarias y lógicas
+				_, _, errBuscar := buscarParticionMontable(file, &mbr, name)
+				// Liberar el disco antes de montar, mountPartition lo vuelve a bloquear
+				cerrar()
 
 				if isMounted {
 					//response.Message = append(response.Message, "La partición ya está montada.")
 					response.Error = fmt.Sprintf("La partición ya está montada.")
 					response.Message = append(response.Message, fmt.Sprintf("La partición ya está montada."))
-				} else if !found {
-					response.Message = append(response.Message, fmt.Sprintf("Error: No se encontró la partición con nombre %s", name))
+				} else if errBuscar != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: No se encontró la partición con nombre %s: %s", name, errBuscar.Error()))
 					//return
 				} else {
-					err = mountPartition(path, name, "201900603")
+					_, _, readOnly, _ := parseMountCommand(cmd)
+					err = mountPartition(path, name, configuracion.Carnet, readOnly)
 					if err != nil {
 						response.Message = append(response.Message, fmt.Sprintf("Error al montar la particion: %s", err.Error()))
 						fmt.Println("Error al montar la partición:", err)
 						return
 					}
-					response.Message = append(response.Message, fmt.Sprintf("Partición montada: Path=%s, Name=%s", path, name))
-					response.Message = append(response.Message, "Particiones montadas:")
-					for id, partition := range mountedPartitions {
-						response.Message = append(response.Message, fmt.Sprintf("ID: %s, Path: %s, Partición: %s, Tamaño: %d bytes, Inicio: %d", id, partition.Path, strings.Trim(string(partition.Partition.PartName[:]), "\x00"), partition.Partition.PartS, partition.Partition.PartStart))
-					}
+					response.Message = append(response.Message, fmt.Sprintf("Partición montada: Path=%s, Name=%s, ReadOnly=%t", path, name, readOnly))
+					response.Message = append(response.Message, printMountedPartitions()...)
 				}
 
+			} else if strings.HasPrefix(cmd, "unmount") {
+				id, err := parseUnmountCommand(cmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := unmountPartition(id); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al desmontar la partición: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Partición desmontada: ID=%s", id))
 			} else if strings.HasPrefix(cmd, "mkfs") {
 				id, fsType, full, err := parseMkfsCommand(cmd)
 				if err != nil {
@@ -334,12 +276,219 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 					fmt.Println("Error:", err)
 					continue
 				}
-				err = formatPartition(id, fsType, full)
-				response.Message = append(response.Message, fmt.Sprintf("Partición formateada: FS=%s, Full=%t", fsType, full))
+				progreso, err := formatPartition(id, fsType, full)
+				response.Message = append(response.Message, progreso...)
 				if err != nil {
 					response.Message = append(response.Message, fmt.Sprintf("Error al formatear la partición: %s", err.Error()))
 					fmt.Println("Error al formatear la partición:", err)
+					continue
 				}
+				response.Message = append(response.Message, fmt.Sprintf("Partición formateada: FS=%s, Full=%t", fsType, full))
+			} else if strings.HasPrefix(cmd, "loss") {
+				id, err := parseLossRecoveryCommand(cmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := perderParticion(id); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al simular la pérdida: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Pérdida simulada en la partición: ID=%s", id))
+			} else if strings.HasPrefix(cmd, "recovery") {
+				id, err := parseLossRecoveryCommand(cmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				entradas, soloEstructura, err := recuperarParticion(id)
+				for _, path := range soloEstructura {
+					response.Message = append(response.Message, fmt.Sprintf("Advertencia: de '%s' solo se recuperó la estructura, el journal no guardaba su contenido", path))
+				}
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al recuperar la partición: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Partición recuperada: ID=%s, Entradas del journal=%d", id, entradas))
+			} else if strings.HasPrefix(cmd, "mkdir") {
+				// Las rutas del sistema de archivos respetan mayúsculas y minúsculas
+				path, padres, err := parseMkdirCarpetaCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := crearCarpeta(path, padres); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al crear la carpeta: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Carpeta creada: Path=%s", path))
+			} else if strings.HasPrefix(cmd, "mkfile") {
+				path, size, cont, recursivo, err := parseMkfileCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := crearArchivo(path, size, cont, recursivo); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al crear el archivo: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Archivo creado: Path=%s", path))
+			} else if strings.HasPrefix(cmd, "edit") {
+				path, cont, err := parseEditCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := editarArchivo(path, cont); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al editar el archivo: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Archivo editado: Path=%s", path))
+			} else if strings.HasPrefix(cmd, "remove") {
+				path, err := parseRemoveCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := eliminarArchivo(path); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al eliminar: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Eliminado: Path=%s", path))
+			} else if strings.HasPrefix(cmd, "rename") {
+				path, name, err := parseRenameCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := renombrarArchivo(path, name); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al renombrar: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Renombrado: Path=%s, Nombre=%s", path, name))
+			} else if strings.HasPrefix(cmd, "copy") {
+				path, destino, err := parseCopyMoveCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := copiarArchivo(path, destino); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al copiar: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Copiado: Path=%s, Destino=%s", path, destino))
+			} else if strings.HasPrefix(cmd, "move") {
+				path, destino, err := parseCopyMoveCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := moverArchivo(path, destino); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al mover: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Movido: Path=%s, Destino=%s", path, destino))
+			} else if strings.HasPrefix(cmd, "find") {
+				path, name, err := parseFindCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				arbol, err := buscarArchivos(path, name)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al buscar: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, arbol)
+			} else if strings.HasPrefix(cmd, "chmod") {
+				path, ugo, recursivo, err := parseChmodCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := cambiarPermisos(path, ugo, recursivo); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al cambiar los permisos: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Permisos cambiados: Path=%s, UGO=%s", path, ugo))
+			} else if strings.HasPrefix(cmd, "chown") {
+				path, usuario, recursivo, err := parseChownCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := cambiarPropietario(path, usuario, recursivo); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al cambiar el dueño: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Dueño cambiado: Path=%s, Usuario=%s", path, usuario))
+			} else if strings.HasPrefix(cmd, "mkgrp") {
+				nombre, err := parseMkgrpCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := crearGrupo(nombre); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al crear el grupo: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Grupo creado: Nombre=%s", nombre))
+			} else if strings.HasPrefix(cmd, "rmgrp") {
+				nombre, err := parseRmgrpCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := eliminarGrupo(nombre); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al eliminar el grupo: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Grupo eliminado: Nombre=%s", nombre))
+			} else if strings.HasPrefix(cmd, "mkusr") {
+				usuario, password, grupo, err := parseMkusrCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := crearUsuario(usuario, password, grupo); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al crear el usuario: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Usuario creado: Usuario=%s, Grupo=%s", usuario, grupo))
+			} else if strings.HasPrefix(cmd, "rmusr") {
+				usuario, err := parseRmusrCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := eliminarUsuario(usuario); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al eliminar el usuario: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Usuario eliminado: Usuario=%s", usuario))
+			} else if strings.HasPrefix(cmd, "chgrp") {
+				usuario, grupo, err := parseChgrpCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				if err := cambiarGrupo(usuario, grupo); err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al cambiar el grupo: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, fmt.Sprintf("Grupo cambiado: Usuario=%s, Grupo=%s", usuario, grupo))
+			} else if strings.HasPrefix(cmd, "cat") {
+				paths, err := parseCatCommand(trimmedCmd)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error: %s", err.Error()))
+					continue
+				}
+				contenido, err := leerArchivos(paths)
+				if err != nil {
+					response.Message = append(response.Message, fmt.Sprintf("Error al leer los archivos: %s", err.Error()))
+					continue
+				}
+				response.Message = append(response.Message, contenido)
 			} else if strings.HasPrefix(cmd, "login") {
 				fmt.Println("Login", user, pass, id)
 				fmt.Println(isLoggedIn)
@@ -387,11 +536,12 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 						continue
 					}
 					//Leer el EBR usando el ID y la posición de inicio de la partición
-					partition := mountedPartitions[id].Partition
+					mounted, _ := motor.montaje(id)
+					partition := mounted.Partition
 					fmt.Println("ID:", id)
 					//fmt.Println("Particion:", partition)
 					fmt.Println("Particion:", partition.PartStart)
-					fmt.Println("Particion:", mountedPartitions[id].Path)
+					fmt.Println("Particion:", mounted.Path)
 
 					// ebrs, err := readAllEBRs(mountedPartitions[id].Path, partition.PartStart)
 					// if err != nil {
@@ -411,7 +561,7 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 					// fmt.Println("Particion encontrada:", path)
 
 					//Generar el reporte del MBR y EBR
-					err = Report_MBR_EBRs(mountedPartitions[id].Path, path)
+					err = Report_MBR_EBRs(mounted.Path, path)
 					fmt.Println("Reporte Generado:", path)
 					if err != nil {
 						response.Error = fmt.Sprintf("Error al generar el reporte: %s", err)
@@ -436,7 +586,8 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 						continue
 					}
 					fmt.Print(mbr)
-					err = generateDiskReport(mbr, path, name)
+					mounted, _ := motor.montaje(id)
+					err = generateDiskReport(mbr, mounted.Path, path, name)
 					if err != nil {
 						response.Error = fmt.Sprintf("Error al generar el reporte: %s", err)
 						return
@@ -510,30 +661,73 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func printMountedPartitions() {
-	response := Response{}
-	if len(mountedPartitions) == 0 {
+// Información de una partición montada para el comando mounted y GET /mounts
+type MountInfo struct {
+	ID         string `json:"id"`
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	Start      int64  `json:"start"`
+	FileSystem string `json:"filesystem"`
+	MountTime  string `json:"mount_time"`
+	MountCount int32  `json:"mount_count"`
+	ReadOnly   bool   `json:"read_only"`
+}
+
+// Lista las particiones montadas ordenadas por ID, con los datos de su SuperBlock
+func listMountedPartitions() []MountInfo {
+	montajes := motor.listarMontajes()
+	mounts := make([]MountInfo, 0, len(montajes))
+	for _, partition := range montajes {
+		id := partition.ID
+		info := MountInfo{
+			ID:         id,
+			Path:       partition.Path,
+			Name:       strings.Trim(string(partition.Partition.PartName[:]), "\x00"),
+			Type:       string(partition.Partition.PartType),
+			Size:       partition.Partition.PartS,
+			Start:      partition.Partition.PartStart,
+			FileSystem: "sin formato",
+			ReadOnly:   partition.ReadOnly,
+		}
+
+		// El tipo de sistema de archivos sale del número mágico del SuperBlock
+		superblock, err := LeerSuperBloquePorID(id)
+		if err == nil && superblock.Magic == 0xEF53 {
+			info.FileSystem = fmt.Sprintf("ext%d", superblock.FilesystemType)
+			info.MountTime = strings.Trim(string(superblock.MountTime[:]), "\x00")
+			info.MountCount = superblock.MountCount
+		}
+		mounts = append(mounts, info)
+	}
+	return mounts
+}
+
+func printMountedPartitions() []string {
+	var messages []string
+	mounts := listMountedPartitions()
+	if len(mounts) == 0 {
 		fmt.Println("No hay particiones montadas.")
-		response.Message = append(response.Message, "No hay particiones montadas.")
-		return
+		return append(messages, "No hay particiones montadas.")
 	}
 
 	fmt.Println("Particiones montadas:")
-	response.Message = append(response.Message, "Particiones montadas:")
-	for id, partition := range mountedPartitions {
-		fmt.Printf("ID: %s, Path: %s, Partición: %s, Tamaño: %d bytes, Inicio: %d\n",
-			id, partition.Path, strings.Trim(string(partition.Partition.PartName[:]), "\x00"),
-			partition.Partition.PartS, partition.Partition.PartStart)
-
-		response.Message = append(response.Message, fmt.Sprintf("ID: %s, Path: %s, Partición: %s, Tamaño: %d bytes, Inicio: %d\n", id, partition.Path, strings.Trim(string(partition.Partition.PartName[:]), "\x00"), partition.Partition.PartS, partition.Partition.PartStart))
+	messages = append(messages, "Particiones montadas:")
+	for _, mount := range mounts {
+		message := fmt.Sprintf("ID: %s, Path: %s, Partición: %s, Tipo: %s, Tamaño: %d bytes, Inicio: %d, Sistema: %s, Montada: %s, Montajes: %d, Solo lectura: %t",
+			mount.ID, mount.Path, mount.Name, mount.Type, mount.Size, mount.Start, mount.FileSystem, mount.MountTime, mount.MountCount, mount.ReadOnly)
+		fmt.Println(message)
+		messages = append(messages, message)
 	}
+	return messages
 }
 
 // Función para leer el MBR desde el archivo usando solo el ID montado
 func readMBR_ID(id string) (MBR, error) {
 	response := Response{}
 	// Buscar la partición montada por ID
-	partition, exists := mountedPartitions[id]
+	partition, exists := motor.montaje(id)
 	if !exists {
 		err := fmt.Errorf("partición con ID '%s' no está montada", id)
 		response.Message = append(response.Message, err.Error())
@@ -545,18 +739,16 @@ func readMBR_ID(id string) (MBR, error) {
 	//fmt.Println("readMBR_ID")
 
 	// Usar el path de la partición montada
-	file, err := os.Open(partition.Path)
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDONLY, 0)
 	if err != nil {
 		err = fmt.Errorf("error al abrir el archivo del disco: %v", err)
 		response.Message = append(response.Message, err.Error())
 		fmt.Println("Error:", err) // Imprime el error para depuración
 		return MBR{}, err
 	}
-	defer file.Close()
+	defer cerrar()
 
-	var mbr MBR
-	mbrData := make([]byte, binary.Size(mbr))
-	_, err = file.ReadAt(mbrData, 0)
+	mbr, err := leerMBR(file)
 	if err != nil {
 		err = fmt.Errorf("error al leer el MBR del disco: %v", err)
 		response.Message = append(response.Message, err.Error())
@@ -564,29 +756,21 @@ func readMBR_ID(id string) (MBR, error) {
 		return MBR{}, err
 	}
 
-	buffer := bytes.NewBuffer(mbrData)
-	if err := binary.Read(buffer, binary.LittleEndian, &mbr); err != nil {
-		err = fmt.Errorf("error al decodificar el MBR: %v", err)
-		response.Message = append(response.Message, err.Error())
-		fmt.Println("Error:", err) // Imprime el error para depuración
-		return MBR{}, err
-	}
-
 	return mbr, nil
 }
 func readEBR_ID(id string, start int64) (*EBR, error) {
 	// Buscar la partición montada por ID
-	partition, exists := mountedPartitions[id]
+	partition, exists := motor.montaje(id)
 	if !exists {
 		return nil, fmt.Errorf("partición con ID '%s' no está montada", id)
 	}
 
 	// Usar el path de la partición montada
-	file, err := os.Open(partition.Path)
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDONLY, 0)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir el archivo del disco: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
 	var ebr EBR
 	if _, err := file.Seek(start, 0); err != nil {
@@ -605,10 +789,16 @@ func readEBR_ID(id string, start int64) (*EBR, error) {
 func getDiscosHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Solicitud GET recibida en /discos")
 	if r.Method == http.MethodGet {
-		mutex.Lock()
-		defer mutex.Unlock()
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(disks)
+		json.NewEncoder(w).Encode(motor.listarDiscos())
+	}
+}
+
+func getMountsHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Solicitud GET recibida en /mounts")
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listMountedPartitions())
 	}
 }
 
@@ -628,8 +818,18 @@ func withCORS(handler http.HandlerFunc) http.HandlerFunc {
 func main() {
 	http.HandleFunc("/execute", withCORS(executeHandler))  // POST para crear discos
 	http.HandleFunc("/discos", withCORS(getDiscosHandler)) // GET para obtener discos
+	http.HandleFunc("/mounts", withCORS(getMountsHandler)) // GET para obtener particiones montadas
 	// http.HandleFunc("/discos/eliminar", deleteDiskHandler) // POST para eliminar discos
 
+	// Restaurar las particiones que estaban montadas antes de reiniciar el servidor
+	messages, err := restaurarMontajes()
+	for _, message := range messages {
+		fmt.Println(message)
+	}
+	if err != nil {
+		fmt.Println("Error al restaurar los montajes:", err)
+	}
+
 	fmt.Println("Server running on port 8080...")
 	http.ListenAndServe(":8080", nil)
 }
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Estado del motor de comandos: discos creados, particiones montadas, usuarios
+// cargados y sesión activa. mu protege esos campos y nunca se mantiene mientras se
+// lee o escribe un disco.
+//
+// Cada archivo de disco tiene además su propio candado de lectura/escritura: los
+// comandos sobre discos distintos se ejecutan en paralelo y los comandos sobre un
+// mismo disco se serializan (las lecturas pueden compartirlo). Cuando se necesitan
+// ambos, primero se toma el candado del disco y después mu.
+type Motor struct {
+	mu       sync.RWMutex
+	discos   []Disk
+	montajes map[string]MountedPartition
+	usuarios map[string]User
+	sesion   Sesion
+
+	tablaMu sync.Mutex // Serializa las escrituras de la tabla de montajes
+
+	candadosMu sync.Mutex
+	candados   map[string]*sync.RWMutex // Candado de cada archivo de disco por ruta
+}
+
+// Sesión iniciada con login
+type Sesion struct {
+	Activa    bool
+	Usuario   string
+	ID        int    // UID del usuario
+	GID       int    // GID del grupo del usuario
+	Particion string // ID de la partición montada donde se inició la sesión
+}
+
+var motor = nuevoMotor()
+
+func nuevoMotor() *Motor {
+	return &Motor{
+		montajes: make(map[string]MountedPartition),
+		usuarios: make(map[string]User),
+		candados: make(map[string]*sync.RWMutex),
+	}
+}
+
+// ------------------------------------CANDADOS-DISCOS--------------------------------
+
+// Devuelve el candado del archivo de disco. La ruta se normaliza para que dos
+// formas de escribir la misma ruta compartan el candado.
+func (m *Motor) candadoDisco(path string) *sync.RWMutex {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.Clean(path)
+
+	m.candadosMu.Lock()
+	defer m.candadosMu.Unlock()
+	candado, existe := m.candados[path]
+	if !existe {
+		candado = &sync.RWMutex{}
+		m.candados[path] = candado
+	}
+	return candado
+}
+
+// Bloquea el disco para escritura o para lectura y devuelve la función que lo libera
+func (m *Motor) bloquearDisco(path string, escritura bool) func() {
+	candado := m.candadoDisco(path)
+	if escritura {
+		candado.Lock()
+		return candado.Unlock
+	}
+	candado.RLock()
+	return candado.RUnlock
+}
+
+// Abre el archivo del disco tomando su candado: de escritura si flag permite
+// escribir o crear el archivo, y de lectura en caso contrario. La función devuelta
+// cierra el archivo y libera el candado.
+func (m *Motor) abrirDisco(path string, flag int, perm os.FileMode) (*os.File, func(), error) {
+	escritura := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0
+	liberar := m.bloquearDisco(path, escritura)
+
+	file, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		liberar()
+		return nil, nil, err
+	}
+	return file, func() {
+		file.Close()
+		liberar()
+	}, nil
+}
+
+// ---------------------------------------DISCOS--------------------------------------
+
+func (m *Motor) agregarDisco(disk Disk) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.discos = append(m.discos, disk)
+}
+
+// Quita el disco de la lista. Devuelve falso si no estaba en la lista.
+func (m *Motor) quitarDisco(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.discos {
+		if m.discos[i].Path == path {
+			m.discos = append(m.discos[:i], m.discos[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Agrega una partición al disco en la lista de discos
+func (m *Motor) agregarParticionDisco(path string, partition Partition) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.discos {
+		if m.discos[i].Path == path {
+			m.discos[i].Partitions = append(m.discos[i].Partitions, partition)
+			return true
+		}
+	}
+	return false
+}
+
+// Copia de la lista de discos, segura para leerla sin el candado
+func (m *Motor) listarDiscos() []Disk {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	discos := make([]Disk, len(m.discos))
+	for i, disk := range m.discos {
+		discos[i] = disk
+		discos[i].Partitions = append([]Partition(nil), disk.Partitions...)
+	}
+	return discos
+}
+
+// -------------------------------------MONTAJES--------------------------------------
+
+// Busca una partición montada por ID
+func (m *Motor) montaje(id string) (MountedPartition, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	partition, existe := m.montajes[id]
+	return partition, existe
+}
+
+// Copia de las particiones montadas ordenadas por ID
+func (m *Motor) listarMontajes() []MountedPartition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	montajes := make([]MountedPartition, 0, len(m.montajes))
+	for _, partition := range m.montajes {
+		montajes = append(montajes, partition)
+	}
+	sort.Slice(montajes, func(i, j int) bool { return montajes[i].ID < montajes[j].ID })
+	return montajes
+}
+
+// Genera el ID de la partición y la registra como montada en una sola sección
+// crítica, así dos montajes simultáneos no reciben el mismo ID. Completa el ID, la
+// letra, el número y el PartId de la partición recibida.
+func (m *Motor) registrarMontaje(carnet string, partition MountedPartition) (MountedPartition, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, letra, numero, err := m.generatePartitionID(carnet, partition.Path)
+	if err != nil {
+		return partition, err
+	}
+	partition.ID = id
+	partition.Letter = letra
+	partition.Number = numero
+	partition.Partition.PartStatus = '1'
+	partition.Partition.PartCorrelative = numero
+	partition.Partition.PartId = [4]byte{}
+	copy(partition.Partition.PartId[:], id)
+
+	m.montajes[id] = partition
+	return partition, nil
+}
+
+// Agrega una partición montada con un ID ya asignado (tabla de montajes). Devuelve
+// falso si el ID ya está en uso.
+func (m *Motor) agregarMontaje(partition MountedPartition) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, existe := m.montajes[partition.ID]; existe {
+		return false
+	}
+	m.montajes[partition.ID] = partition
+	return true
+}
+
+// Quita una partición montada y la devuelve
+func (m *Motor) quitarMontaje(id string) (MountedPartition, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	partition, existe := m.montajes[id]
+	delete(m.montajes, id)
+	return partition, existe
+}
+
+// --------------------------------------SESIÓN---------------------------------------
+
+func (m *Motor) sesionActual() Sesion {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sesion
+}
+
+// Inicia la sesión del usuario en la partición si no hay otra sesión activa y la
+// contraseña coincide. usuarios son los usuarios leídos de users.txt de la partición.
+func (m *Motor) iniciarSesion(usuarios map[string]User, username, password, particion string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Verificar si ya hay una sesión activa
+	if m.sesion.Activa {
+		return errors.New("ya hay una sesión activa, cierre sesión antes de iniciar una nueva")
+	}
+
+	// Verificar si el usuario existe en el mapa cargado desde users.txt
+	user, exists := usuarios[username]
+	if !exists {
+		return errors.New("usuario no encontrado")
+	}
+
+	// Verificar la contraseña
+	if user.Password != password {
+		return errors.New("contraseña incorrecta")
+	}
+
+	m.usuarios = usuarios
+	m.sesion = Sesion{Activa: true, Usuario: username, ID: user.ID, GID: user.GID, Particion: particion}
+	return nil
+}
+
+// Reemplaza los usuarios cargados después de modificar users.txt en la partición de
+// la sesión. Si el usuario de la sesión cambió de grupo, la sesión usa el grupo nuevo.
+func (m *Motor) actualizarUsuarios(usuarios map[string]User) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usuarios = usuarios
+	if user, existe := usuarios[m.sesion.Usuario]; existe && m.sesion.Activa {
+		m.sesion.GID = user.GID
+	}
+}
+
+// Cierra la sesión activa
+func (m *Motor) cerrarSesion() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.sesion.Activa {
+		return errors.New("no hay una sesión activa para cerrar")
+	}
+	m.sesion = Sesion{}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Estas pruebas están pensadas para ejecutarse con go test -race
+
+func TestRegistrarMontajeConcurrenteSinIDsDuplicados(t *testing.T) {
+	m := nuevoMotor()
+	const discos, particiones = 4, 8
+
+	var wg sync.WaitGroup
+	errores := make(chan error, discos*particiones)
+	for d := 0; d < discos; d++ {
+		for p := 0; p < particiones; p++ {
+			wg.Add(1)
+			go func(d, p int) {
+				defer wg.Done()
+				plantilla := MountedPartition{Path: fmt.Sprintf("/discos/d%d.mia", d)}
+				copy(plantilla.Partition.PartName[:], fmt.Sprintf("p%d", p))
+				if _, err := m.registrarMontaje("201900603", plantilla); err != nil {
+					errores <- err
+				}
+			}(d, p)
+		}
+	}
+	wg.Wait()
+	close(errores)
+	for err := range errores {
+		t.Fatalf("registrarMontaje: %v", err)
+	}
+
+	montajes := m.listarMontajes()
+	if len(montajes) != discos*particiones {
+		t.Fatalf("se registraron %d montajes, se esperaban %d", len(montajes), discos*particiones)
+	}
+	letras := make(map[string]byte)
+	numeros := make(map[string]bool)
+	for _, montaje := range montajes {
+		if letra, existe := letras[montaje.Path]; existe && letra != montaje.Letter {
+			t.Errorf("el disco %s tiene las letras %c y %c", montaje.Path, letra, montaje.Letter)
+		}
+		letras[montaje.Path] = montaje.Letter
+		clave := fmt.Sprintf("%s/%d", montaje.Path, montaje.Number)
+		if numeros[clave] {
+			t.Errorf("número %d repetido en el disco %s", montaje.Number, montaje.Path)
+		}
+		numeros[clave] = true
+		if string(montaje.Partition.PartId[:len(montaje.ID)]) != montaje.ID {
+			t.Errorf("PartId %q no coincide con el ID %q", montaje.Partition.PartId, montaje.ID)
+		}
+	}
+	usadas := make(map[byte]string)
+	for path, letra := range letras {
+		if otro, existe := usadas[letra]; existe {
+			t.Errorf("los discos %s y %s comparten la letra %c", path, otro, letra)
+		}
+		usadas[letra] = path
+	}
+}
+
+func TestAbrirDiscoSerializaRutasEquivalentes(t *testing.T) {
+	m := nuevoMotor()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "a.mia")
+	if err := os.WriteFile(path, make([]byte, 8), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relativa, err := filepath.Rel(cwd, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rutas := []string{path, filepath.Join(dir, "sub", "..", "a.mia"), relativa}
+
+	for _, ruta := range rutas[1:] {
+		if m.candadoDisco(ruta) != m.candadoDisco(path) {
+			t.Fatalf("'%s' y '%s' no comparten el candado", ruta, path)
+		}
+	}
+
+	// Cada escritor lee el contador del disco, espera y escribe el contador más uno;
+	// sin exclusión mutua se pierden incrementos
+	const porRuta = 20
+	var wg sync.WaitGroup
+	for _, ruta := range rutas {
+		for i := 0; i < porRuta; i++ {
+			wg.Add(1)
+			go func(ruta string) {
+				defer wg.Done()
+				file, cerrar, err := m.abrirDisco(ruta, os.O_RDWR, 0644)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer cerrar()
+				var contador int64
+				if err := binary.Read(file, binary.LittleEndian, &contador); err != nil {
+					t.Error(err)
+					return
+				}
+				time.Sleep(time.Millisecond)
+				if _, err := file.Seek(0, 0); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := binary.Write(file, binary.LittleEndian, contador+1); err != nil {
+					t.Error(err)
+				}
+			}(ruta)
+		}
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contador := int64(binary.LittleEndian.Uint64(data)); contador != int64(len(rutas)*porRuta) {
+		t.Fatalf("contador = %d, se esperaba %d", contador, len(rutas)*porRuta)
+	}
+}
+
+func TestAbrirDiscoLiberaElCandadoSiFallaLaApertura(t *testing.T) {
+	m := nuevoMotor()
+	path := filepath.Join(t.TempDir(), "no_existe.mia")
+	if _, _, err := m.abrirDisco(path, os.O_RDWR, 0644); err == nil {
+		t.Fatal("se esperaba un error al abrir un disco inexistente")
+	}
+	listo := make(chan struct{})
+	go func() {
+		m.bloquearDisco(path, true)()
+		close(listo)
+	}()
+	select {
+	case <-listo:
+	case <-time.After(2 * time.Second):
+		t.Fatal("el candado del disco quedó tomado después de un error")
+	}
+}
+
+func TestSesionConcurrente(t *testing.T) {
+	m := nuevoMotor()
+	usuarios := map[string]User{
+		"root": {ID: 1, GID: 1, Username: "root", Password: "123", Group: "root"},
+		"ana":  {ID: 2, GID: 2, Username: "ana", Password: "abc", Group: "devs"},
+	}
+
+	const intentos = 50
+	var iniciadas, cerradas int32
+	var wg sync.WaitGroup
+	for i := 0; i < intentos; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			usuario, password := "root", "123"
+			if i%2 == 1 {
+				usuario, password = "ana", "abc"
+			}
+			if m.iniciarSesion(usuarios, usuario, password, "031a") == nil {
+				atomic.AddInt32(&iniciadas, 1)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			sesion := m.sesionActual()
+			if sesion.Activa && (sesion.Usuario == "" || sesion.Particion != "031a") {
+				t.Errorf("sesión incompleta: %+v", sesion)
+			}
+		}()
+	}
+	wg.Wait()
+	if iniciadas != 1 {
+		t.Fatalf("se iniciaron %d sesiones, se esperaba una", iniciadas)
+	}
+	sesion := m.sesionActual()
+	if esperado := usuarios[sesion.Usuario]; sesion.ID != esperado.ID || sesion.GID != esperado.GID {
+		t.Fatalf("la sesión %+v no corresponde al usuario %+v", sesion, esperado)
+	}
+
+	for i := 0; i < intentos; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.cerrarSesion() == nil {
+				atomic.AddInt32(&cerradas, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if cerradas != 1 {
+		t.Fatalf("se cerraron %d sesiones, se esperaba una", cerradas)
+	}
+	if m.sesionActual().Activa {
+		t.Fatal("la sesión sigue activa después de cerrarla")
+	}
+
+	if err := m.iniciarSesion(usuarios, "ana", "mal", "031a"); err == nil {
+		t.Fatal("se inició sesión con una contraseña incorrecta")
+	}
+	if err := m.iniciarSesion(usuarios, "nadie", "123", "031a"); err == nil {
+		t.Fatal("se inició sesión con un usuario inexistente")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Separa los parámetros de un comando del sistema de archivos en un mapa
+// nombre -> valor. Los nombres se pasan a minúsculas y los valores conservan sus
+// mayúsculas; un valor entre comillas puede contener espacios. Las banderas sin
+// valor (como -p o -r) quedan con el valor "". Solo se aceptan los parámetros de
+// permitidos; si no se indica ninguno se acepta cualquier parámetro.
+func leerParametros(command string, permitidos ...string) (map[string]string, error) {
+	command = strings.TrimSpace(strings.SplitN(command, "#", 2)[0])
+
+	// Separar en palabras respetando las comillas
+	var palabras []string
+	var actual strings.Builder
+	enComillas := false
+	for _, c := range command {
+		switch {
+		case c == '"':
+			enComillas = !enComillas
+		case (c == ' ' || c == '\t') && !enComillas:
+			if actual.Len() > 0 {
+				palabras = append(palabras, actual.String())
+				actual.Reset()
+			}
+		default:
+			actual.WriteRune(c)
+		}
+	}
+	if enComillas {
+		return nil, fmt.Errorf("comillas sin cerrar en el comando")
+	}
+	if actual.Len() > 0 {
+		palabras = append(palabras, actual.String())
+	}
+
+	parametros := make(map[string]string)
+	if len(palabras) == 0 {
+		return parametros, nil
+	}
+	for _, palabra := range palabras[1:] {
+		if !strings.HasPrefix(palabra, "-") {
+			return nil, fmt.Errorf("parámetro inválido: %s", palabra)
+		}
+		nombre, valor := palabra, ""
+		if i := strings.Index(palabra, "="); i >= 0 {
+			nombre, valor = palabra[:i], palabra[i+1:]
+		}
+		nombre = strings.ToLower(strings.TrimPrefix(nombre, "-"))
+
+		permitido := len(permitidos) == 0
+		for _, p := range permitidos {
+			if p == nombre {
+				permitido = true
+				break
+			}
+		}
+		if !permitido {
+			return nil, fmt.Errorf("parámetro inválido: -%s", nombre)
+		}
+		parametros[nombre] = valor
+	}
+	return parametros, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ----------------------------------------CHMOD--------------------------------------
+// Analiza el comando chmod: -path, -ugo con los tres dígitos de permisos (0 a 7) del
+// dueño, del grupo y de otros, y -r para aplicarlo a todo el contenido de una carpeta
+func parseChmodCommand(command string) (path, ugo string, recursivo bool, err error) {
+	parametros, err := leerParametros(command, "path", "ugo", "r")
+	if err != nil {
+		return "", "", false, err
+	}
+	path, ugo = parametros["path"], parametros["ugo"]
+	_, recursivo = parametros["r"]
+	if path == "" {
+		return "", "", false, fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if err := validarPermisos(ugo); err != nil {
+		return "", "", false, err
+	}
+	return path, ugo, recursivo, nil
+}
+
+func validarPermisos(ugo string) error {
+	if len(ugo) != 3 {
+		return fmt.Errorf("el parámetro -ugo debe tener tres dígitos del 0 al 7")
+	}
+	for _, c := range ugo {
+		if c < '0' || c > '7' {
+			return fmt.Errorf("el parámetro -ugo debe tener tres dígitos del 0 al 7")
+		}
+	}
+	return nil
+}
+
+// Comando chmod: cambia los permisos del archivo o carpeta
+func cambiarPermisos(path, ugo string, recursivo bool) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("chmod", path, fmt.Sprintf("-ugo=%s%s", ugo, banderaRecursiva(recursivo)), func() error {
+			return fs.cambiarPermisos(path, ugo, recursivo)
+		})
+	})
+}
+
+func (fs *sistemaArchivos) cambiarPermisos(ruta, ugo string, recursivo bool) error {
+	if err := validarPermisos(ugo); err != nil {
+		return err
+	}
+	return fs.cambiarInodos(ruta, recursivo, func(inodo *Inode) {
+		copy(inodo.Perms[:], ugo)
+	})
+}
+
+// ----------------------------------------CHOWN--------------------------------------
+// Analiza el comando chown: -path, -usuario con el nuevo dueño y -r para aplicarlo a
+// todo el contenido de una carpeta
+func parseChownCommand(command string) (path, usuario string, recursivo bool, err error) {
+	parametros, err := leerParametros(command, "path", "usuario", "r")
+	if err != nil {
+		return "", "", false, err
+	}
+	path, usuario = parametros["path"], parametros["usuario"]
+	_, recursivo = parametros["r"]
+	if path == "" {
+		return "", "", false, fmt.Errorf("el parámetro -path es obligatorio")
+	}
+	if usuario == "" {
+		return "", "", false, fmt.Errorf("el parámetro -usuario es obligatorio")
+	}
+	return path, usuario, recursivo, nil
+}
+
+// Comando chown: cambia el dueño del archivo o carpeta
+func cambiarPropietario(path, usuario string, recursivo bool) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return fs.conJournal("chown", path, fmt.Sprintf("-usuario=\"%s\"%s", usuario, banderaRecursiva(recursivo)), func() error {
+			return fs.cambiarPropietario(path, usuario, recursivo)
+		})
+	})
+}
+
+// Cambia el dueño al usuario de users.txt con ese nombre
+func (fs *sistemaArchivos) cambiarPropietario(ruta, usuario string, recursivo bool) error {
+	usuarios, err := loadUsers(fs.file, fs.superblock)
+	if err != nil {
+		return err
+	}
+	user, existe := usuarios[usuario]
+	if !existe {
+		return fmt.Errorf("el usuario '%s' no existe", usuario)
+	}
+	return fs.cambiarInodos(ruta, recursivo, func(inodo *Inode) {
+		inodo.UID = int32(user.ID)
+	})
+}
+
+// ------------------------------------------------------------------------------------
+
+func banderaRecursiva(recursivo bool) string {
+	if recursivo {
+		return " -r"
+	}
+	return ""
+}
+
+// Aplica cambiar al inodo de la ruta y, con recursivo, a todo el contenido de la
+// carpeta. La sesión debe ser dueña del inodo de la ruta; dentro de la carpeta se
+// omiten los inodos de otros dueños.
+func (fs *sistemaArchivos) cambiarInodos(ruta string, recursivo bool, cambiar func(inodo *Inode)) error {
+	indice, inodo, err := fs.resolverRuta(ruta)
+	if err != nil {
+		return err
+	}
+	if err := fs.autorizarPropietario(inodo, ruta); err != nil {
+		return err
+	}
+	return fs.cambiarArbol(indice, inodo, recursivo, cambiar)
+}
+
+func (fs *sistemaArchivos) cambiarArbol(indice int32, inodo *Inode, recursivo bool, cambiar func(inodo *Inode)) error {
+	// Las entradas se leen antes del cambio, que puede quitar el permiso de lectura.
+	// Las carpetas que la sesión no puede recorrer no se recorren.
+	var entradas []entradaCarpeta
+	if recursivo && inodo.Type == DirType && fs.tienePermiso(inodo, Read|Exec) {
+		var err error
+		if entradas, err = fs.entradasCarpeta(inodo); err != nil {
+			return err
+		}
+	}
+
+	cambiar(inodo)
+	if err := fs.escribirInodo(indice, inodo); err != nil {
+		return err
+	}
+
+	for _, entrada := range entradas {
+		if entrada.Nombre == "." || entrada.Nombre == ".." {
+			continue
+		}
+		hijo, err := fs.leerInodo(entrada.Inodo)
+		if err != nil {
+			return err
+		}
+		if !fs.esPropietario(hijo) {
+			continue
+		}
+		if err := fs.cambiarArbol(entrada.Inodo, hijo, recursivo, cambiar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------LOSS-RECOVERY----------------------------------
+// Analiza los comandos loss y recovery, que solo reciben el ID de la partición
+func parseLossRecoveryCommand(command2 string) (id string, err error) {
+	params := strings.Fields(strings.ToLower(command2))
+	for _, param := range params {
+		if strings.HasPrefix(param, "-id=") {
+			id = strings.TrimPrefix(param, "-id=")
+			id = strings.Trim(id, "\"")
+		}
+	}
+
+	if id == "" {
+		return "", fmt.Errorf("el parámetro -id es obligatorio")
+	}
+	return id, nil
+}
+
+// Abre el disco de una partición montada con EXT3 y lee su SuperBlock. cerrar
+// libera el disco.
+func abrirParticionEXT3(id string) (MountedPartition, *os.File, func(), *SuperBlock, error) {
+	id = strings.ToLower(id)
+	partition, exists := motor.montaje(id)
+	if !exists {
+		return partition, nil, nil, nil, fmt.Errorf("partición con ID '%s' no está montada", id)
+	}
+	if err := verificarEscritura(id); err != nil {
+		return partition, nil, nil, nil, err
+	}
+
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDWR, 0644)
+	if err != nil {
+		return partition, nil, nil, nil, fmt.Errorf("Error al abrir el disco: %v", err)
+	}
+	superblock, err := leerSuperBloque(file, partition.Partition.PartStart)
+	if err != nil {
+		cerrar()
+		return partition, nil, nil, nil, err
+	}
+	if superblock.FilesystemType != 3 {
+		cerrar()
+		return partition, nil, nil, nil, fmt.Errorf("la partición '%s' no es EXT3 y no tiene journal", id)
+	}
+	return partition, file, cerrar, superblock, nil
+}
+
+// Limpia los bitmaps, la tabla de inodos y los bloques. El SuperBlock y el journal
+// no se modifican.
+func limpiarEstructuras(file *os.File, superblock *SuperBlock) error {
+	fin := int64(superblock.BlockStart) + int64(superblock.BlocksCount)*int64(superblock.BlockSize)
+	return escribirCeros(file, int64(superblock.BmInodeStart), fin-int64(superblock.BmInodeStart), nil)
+}
+
+// Simula una falla del disco: pierde todo el contenido del sistema de archivos
+// excepto el SuperBlock y el journal
+func perderParticion(id string) error {
+	_, file, cerrar, superblock, err := abrirParticionEXT3(id)
+	if err != nil {
+		return err
+	}
+	defer cerrar()
+
+	if err := limpiarEstructuras(file, superblock); err != nil {
+		return err
+	}
+	fmt.Printf("Partición con ID '%s' perdida: se limpiaron los bitmaps, los inodos y los bloques.\n", id)
+	return nil
+}
+
+// Reconstruye el sistema de archivos: vuelve a crear la estructura inicial de mkfs
+// y repite las entradas del journal en orden. El contenido de mkfile -cont y edit
+// se toma de las entradas "datos" que siguen a la operación. Devuelve el número de
+// entradas repetidas y las rutas de los archivos de los que solo se recuperó la
+// estructura: los journals anteriores a las entradas "datos" guardaban solo el
+// tamaño de ese contenido (-externo), y esos archivos se restauran con el patrón
+// 0123456789.
+//
+// Si una entrada falla, las anteriores quedan aplicadas y el SuperBlock se guarda
+// igual, para que sus contadores coincidan con los bitmaps.
+func recuperarParticion(id string) (repetidas int, soloEstructura []string, err error) {
+	partition, file, cerrar, superblock, err := abrirParticionEXT3(id)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer cerrar()
+	start := partition.Partition.PartStart
+
+	entradas, err := leerJournal(file, superblock, start)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(entradas) == 0 || strings.Trim(string(entradas[0].Operation[:]), "\x00") != "mkfs" {
+		return 0, nil, fmt.Errorf("el journal de la partición '%s' no inicia con mkfs", id)
+	}
+
+	// Estructura inicial, igual que en formatPartition
+	if err := limpiarEstructuras(file, superblock); err != nil {
+		return 0, nil, err
+	}
+	superblock.FreeInodesCount = superblock.InodesCount
+	superblock.FreeBlocksCount = superblock.BlocksCount
+	superblock.FirstInode = 0
+	superblock.FirstBlock = 0
+	if err := initializeBitmaps(file, superblock); err != nil {
+		return 0, nil, fmt.Errorf("Error al inicializar los mapas de bits: %v", err)
+	}
+	if err := createUsersFile(file, superblock); err != nil {
+		return 0, nil, fmt.Errorf("Error al crear el archivo users.txt: %v", err)
+	}
+
+	repetidas = 1
+	for i := 1; i < len(entradas); {
+		entrada := entradas[i]
+		consumidas := 1
+		var datos []byte
+		operacion, path, parametros, err := leerEntradaJournal(entrada)
+		if err == nil {
+			datos, consumidas, err = datosJournal(entradas[i:], parametros)
+		}
+		if err == nil {
+			err = repetirEntradaJournal(file, superblock, start, operacion, path, parametros, datos)
+		}
+		if err != nil {
+			if errGuardar := escribirSuperBloque(file, start, superblock); errGuardar != nil {
+				return repetidas, soloEstructura, errGuardar
+			}
+			return repetidas, soloEstructura, fmt.Errorf("Error al repetir la entrada %d del journal (%s %s), se repitieron %d de %d entradas: %v",
+				entrada.Count, operacion, path, repetidas, len(entradas), err)
+		}
+		repetidas += consumidas
+		i += consumidas
+		if (operacion == "mkfile" || operacion == "edit") && contenidoExterno(parametros) {
+			soloEstructura = append(soloEstructura, path)
+		}
+	}
+
+	if err := escribirSuperBloque(file, start, superblock); err != nil {
+		return repetidas, soloEstructura, err
+	}
+	fmt.Printf("Partición con ID '%s' recuperada con %d entradas del journal.\n", id, repetidas)
+	return repetidas, soloEstructura, nil
+}
+
+// Separa una entrada del journal en la operación, la ruta y los parámetros
+func leerEntradaJournal(entrada Journal) (operacion, path string, parametros map[string]string, err error) {
+	operacion = strings.Trim(string(entrada.Operation[:]), "\x00")
+	path = strings.Trim(string(entrada.Path[:]), "\x00")
+	parametros, err = leerParametros(operacion + " " + strings.Trim(string(entrada.Content[:]), "\x00"))
+	return operacion, path, parametros, err
+}
+
+// Contenido guardado en las entradas "datos" que siguen a entradas[0] si sus
+// parámetros tienen -datos, y el número de entradas que ocupa la operación
+func datosJournal(entradas []Journal, parametros map[string]string) ([]byte, int, error) {
+	if _, existe := parametros["datos"]; !existe {
+		return nil, 1, nil
+	}
+	size, err := strconv.Atoi(parametros["size"])
+	if err != nil || size < 0 {
+		return nil, 1, fmt.Errorf("tamaño '%s' inválido para las entradas de datos", parametros["size"])
+	}
+	tamano := len(Journal{}.Content)
+	partes := (size + tamano - 1) / tamano
+	if len(entradas)-1 < partes {
+		return nil, 1, fmt.Errorf("faltan entradas de datos: se esperaban %d y hay %d", partes, len(entradas)-1)
+	}
+	datos := make([]byte, 0, partes*tamano)
+	for _, entrada := range entradas[1 : partes+1] {
+		if strings.Trim(string(entrada.Operation[:]), "\x00") != operacionDatos {
+			return nil, 1, fmt.Errorf("la entrada %d no es de datos", entrada.Count)
+		}
+		datos = append(datos, entrada.Content[:]...)
+	}
+	return datos[:size], partes + 1, nil
+}
+
+// Indica si la entrada de mkfile o edit tenía el contenido de un archivo del
+// servidor. Las entradas antiguas guardaban la ruta en -cont; las nuevas solo
+// marcan -externo.
+func contenidoExterno(parametros map[string]string) bool {
+	_, externo := parametros["externo"]
+	_, cont := parametros["cont"]
+	return externo || cont
+}
+
+// Repite una entrada del journal sobre la partición. Cada comando que registra
+// entradas en el journal agrega aquí su caso y llama a la misma función que usa el
+// comando, sin volver a registrar la entrada.
+func repetirEntradaJournal(file *os.File, superblock *SuperBlock, start int64, operacion, path string, parametros map[string]string, datos []byte) error {
+	// La operación se repite con el usuario que la ejecutó originalmente
+	uid, _ := strconv.Atoi(parametros["uid"])
+	gid, _ := strconv.Atoi(parametros["gid"])
+	fs := &sistemaArchivos{
+		file:       file,
+		superblock: superblock,
+		inicio:     start,
+		sesion:     Sesion{Activa: true, ID: uid, GID: gid},
+	}
+
+	switch operacion {
+	case "mkfs":
+		// El formateo ya se repitió al reconstruir la estructura inicial
+		return nil
+	case "mkdir":
+		_, padres := parametros["p"]
+		return fs.crearCarpeta(path, padres)
+	case "mkfile":
+		_, recursivo := parametros["r"]
+		return fs.crearArchivo(path, contenidoJournal(parametros, datos), recursivo)
+	case "edit":
+		if contenidoExterno(parametros) {
+			// Con el patrón en lugar de los usuarios nadie podría iniciar sesión
+			if indice, _, err := fs.resolverRuta(path); err == nil && indice == inodoUsuarios {
+				return fmt.Errorf("la entrada solo guarda el tamaño del contenido nuevo de %s, no se puede repetir", rutaUsuarios)
+			}
+		}
+		return fs.editarArchivo(path, contenidoJournal(parametros, datos))
+	case "remove":
+		return fs.eliminar(path)
+	case "rename":
+		return fs.renombrar(path, parametros["name"])
+	case "chmod":
+		_, recursivo := parametros["r"]
+		return fs.cambiarPermisos(path, parametros["ugo"], recursivo)
+	case "chown":
+		_, recursivo := parametros["r"]
+		return fs.cambiarPropietario(path, parametros["usuario"], recursivo)
+	case "mkgrp":
+		return fs.crearGrupo(parametros["name"])
+	case "rmgrp":
+		return fs.eliminarGrupo(parametros["name"])
+	case "mkusr":
+		return fs.crearUsuario(parametros["user"], parametros["pass"], parametros["grp"])
+	case "rmusr":
+		return fs.eliminarUsuario(parametros["user"])
+	case "chgrp":
+		return fs.cambiarGrupo(parametros["user"], parametros["grp"])
+	case "copy":
+		return fs.copiar(path, parametros["destino"])
+	case "move":
+		return fs.mover(path, parametros["destino"])
+	case operacionDatos:
+		return fmt.Errorf("entrada de datos sin la operación que la registró")
+	default:
+		return fmt.Errorf("operación '%s' no soportada", operacion)
+	}
+}
+
+// Contenido de mkfile y edit al repetirlos: el de las entradas de datos si la
+// operación las tiene, o -size bytes con el patrón 0123456789. Nunca se vuelve a
+// leer el archivo del servidor, que pudo cambiar o desaparecer.
+func contenidoJournal(parametros map[string]string, datos []byte) []byte {
+	if _, existe := parametros["datos"]; existe {
+		return datos
+	}
+	size, _ := strconv.Atoi(parametros["size"])
+	contenido, _ := contenidoMkfile(size, "")
+	return contenido
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Los archivos creados o editados con -cont se recuperan con el contenido guardado
+// en el journal, sin volver a leer el archivo del servidor
+func TestRecuperarContenidoDeArchivosDelServidor(t *testing.T) {
+	prepararSistemaArchivos(t, 256*1024, "ext3")
+	cont := filepath.Join(t.TempDir(), "cont.txt")
+	if err := os.WriteFile(cont, []byte("hola mundo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/a.txt", 0, cont, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/docs/b.txt", 25, "", true); err != nil {
+		t.Fatal(err)
+	}
+	// Más de una entrada de datos y bytes en cero al final
+	largo := append(bytes.Repeat([]byte("contenido más largo "), 12), 0, 0)
+	if err := os.WriteFile(cont, largo, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := editarArchivo("/docs/b.txt", cont); err != nil {
+		t.Fatal(err)
+	}
+	if err := crearArchivo("/c.txt", 12, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(cont); err != nil {
+		t.Fatal(err)
+	}
+
+	total := len(entradasJournalPrueba(t))
+	if err := perderParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	repetidas, soloEstructura, err := recuperarParticion("990a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repetidas != total {
+		t.Errorf("se repitieron %d entradas de %d", repetidas, total)
+	}
+	if len(soloEstructura) != 0 {
+		t.Errorf("archivos con solo la estructura: %q", soloEstructura)
+	}
+
+	esperados := map[string]string{
+		"/a.txt":      "hola mundo",
+		"/docs/b.txt": string(largo),
+		"/c.txt":      "012345678901",
+	}
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		verificarContadores(t, fs)
+		for ruta, esperado := range esperados {
+			contenido, err := fs.leerArchivo(ruta)
+			if err != nil {
+				return err
+			}
+			if string(contenido) != esperado {
+				t.Errorf("contenido recuperado de %s = %q, se esperaba %q", ruta, contenido, esperado)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Las entradas -externo de journals anteriores, que solo guardaban el tamaño, se
+// recuperan con el patrón y se informan
+func TestRecuperarSoloEstructuraDeEntradasExternas(t *testing.T) {
+	prepararSistemaArchivos(t, 256*1024, "ext3")
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return registrarJournal(fs.file, fs.superblock, fs.inicio, "mkfile", "/a.txt", "-size=10 -externo -uid=1 -gid=1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := perderParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	_, soloEstructura, err := recuperarParticion("990a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(soloEstructura, []string{"/a.txt"}) {
+		t.Errorf("archivos con solo la estructura: %q", soloEstructura)
+	}
+	if contenido, err := leerArchivos([]string{"/a.txt"}); err != nil || contenido != "0123456789" {
+		t.Errorf("contenido de /a.txt: %q, %v", contenido, err)
+	}
+}
+
+// Si una entrada no se puede repetir, las anteriores quedan aplicadas y el
+// SuperBlock se guarda con los contadores de los bitmaps
+func TestRecuperarGuardaElSuperBloqueSiFallaUnaEntrada(t *testing.T) {
+	prepararSistemaArchivos(t, 256*1024, "ext3")
+	if err := crearArchivo("/docs/a.txt", 200, "", true); err != nil {
+		t.Fatal(err)
+	}
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return registrarJournal(fs.file, fs.superblock, fs.inicio, "mkdir", "/no/existe", "-uid=1 -gid=1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crearCarpeta("/despues", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := perderParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	repetidas, _, err := recuperarParticion("990a")
+	if err == nil {
+		t.Fatal("recovery no informó la entrada que falló")
+	}
+	if repetidas != 2 {
+		t.Errorf("se repitieron %d entradas, se esperaban 2", repetidas)
+	}
+
+	err = consultarSistemaArchivos(func(fs *sistemaArchivos) error {
+		verificarContadores(t, fs)
+		if _, err := fs.leerArchivo("/docs/a.txt"); err != nil {
+			t.Errorf("no se recuperó la entrada anterior a la falla: %v", err)
+		}
+		if _, _, err := fs.resolverRuta("/despues"); err == nil {
+			t.Error("se repitió una entrada posterior a la falla")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Un edit de users.txt se recupera con su contenido y los usuarios agregados pueden
+// iniciar sesión; una entrada -externo antigua sobre users.txt no se repite con el
+// patrón
+func TestRecuperarEdicionDeUsuarios(t *testing.T) {
+	prepararSistemaArchivos(t, 256*1024, "ext3")
+	usuarios := "1,G,root\n1,U,root,root,123\n2,G,devs\n2,U,devs,ana,abc\n"
+	cont := filepath.Join(t.TempDir(), "users.txt")
+	if err := os.WriteFile(cont, []byte(usuarios), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := editarArchivo(rutaUsuarios, cont); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(cont); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := perderParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := recuperarParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	if contenido, err := leerArchivos([]string{rutaUsuarios}); err != nil || contenido != usuarios {
+		t.Fatalf("users.txt recuperado: %q, %v", contenido, err)
+	}
+	cambiarSesionPrueba(t, "ana", "abc")
+
+	// Entrada de un journal anterior que no guardaba el contenido
+	cambiarSesionPrueba(t, "root", "123")
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		return registrarJournal(fs.file, fs.superblock, fs.inicio, "edit", rutaUsuarios, "-size=40 -externo -uid=1 -gid=1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := perderParticion("990a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := recuperarParticion("990a"); err == nil {
+		t.Fatal("recovery repitió un edit de users.txt sin su contenido")
+	}
+	if contenido, err := leerArchivos([]string{rutaUsuarios}); err != nil || contenido != usuarios {
+		t.Fatalf("users.txt después del edit sin contenido: %q, %v", contenido, err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -87,16 +88,16 @@ func parseRepCommand(command2 string) (id string, path string, name string, path
 
 func imprimirMBR_Partitions(path string) {
 	// Abrir el archivo del disco
-	file, err := os.Open(path)
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDONLY, 0)
 	if err != nil {
 		fmt.Println("Error al abrir el archivo del disco:", err)
 		return
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(file, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(file)
+	if err != nil {
 		fmt.Println("Error al leer el MBR:", err)
 		return
 	}
@@ -143,16 +144,16 @@ func Report_MBR_EBRs(path_disk string, path string) error {
 	defer file.Close()
 
 	// Abrir el archivo del disco
-	files, err := os.Open(path_disk)
+	files, cerrar, err := motor.abrirDisco(path_disk, os.O_RDONLY, 0)
 	if err != nil {
 		fmt.Println("Error al abrir el archivo del disco:", err)
 		return err
 	}
-	defer files.Close()
+	defer cerrar()
 
 	// Leer el MBR existente
-	var mbr MBR
-	if err := binary.Read(files, binary.LittleEndian, &mbr); err != nil {
+	mbr, err := leerMBR(files)
+	if err != nil {
 		fmt.Println("Error al leer el MBR:", err)
 		return err
 	}
@@ -174,6 +175,8 @@ func Report_MBR_EBRs(path_disk string, path string) error {
 	fmt.Fprintf(file, "            <TR><TD ALIGN=\"LEFT\">CreatedAt:</TD><TD ALIGN=\"LEFT\">%s</TD></TR>\n", string(mbr.MbrFechaCreacion[:]))
 	fmt.Fprintf(file, "            <TR><TD ALIGN=\"LEFT\">Signature:</TD><TD ALIGN=\"LEFT\">%d</TD></TR>\n", mbr.MbrDskSignature)
 	fmt.Fprintf(file, "            <TR><TD ALIGN=\"LEFT\">Fit:</TD><TD ALIGN=\"LEFT\">%c</TD></TR>\n", mbr.DskFit)
+	fmt.Fprintf(file, "            <TR><TD ALIGN=\"LEFT\">Alignment:</TD><TD ALIGN=\"LEFT\">%d bytes</TD></TR>\n", alineacionMBR(mbr))
+	fmt.Fprintf(file, "            <TR><TD ALIGN=\"LEFT\">Alignment waste:</TD><TD ALIGN=\"LEFT\">%d bytes</TD></TR>\n", desperdicioAlineacion(mbr, leerEBRsDelMBR(files, mbr)))
 	fmt.Fprintln(file, "        </TABLE>")
 	fmt.Fprintln(file, "        >];")
 	fmt.Fprintln(file, "    }")
@@ -207,8 +210,10 @@ func Report_MBR_EBRs(path_disk string, path string) error {
 				var ebr EBR
 				currentPosition := partition.PartStart
 				ebrIndex := 1
+				visitados := make(map[int64]bool)
 
-				for {
+				for !visitados[currentPosition] {
+					visitados[currentPosition] = true
 					if _, err := files.Seek(currentPosition, 0); err != nil {
 						fmt.Println("Error al posicionarse en el EBR:", err)
 						return err
@@ -227,7 +232,7 @@ func Report_MBR_EBRs(path_disk string, path string) error {
 					fmt.Fprintln(file, "                <TR>")
 					fmt.Fprintln(file, "                    <TD COLSPAN=\"2\" BGCOLOR=\"lightgrey\"><B>EBR</B></TD>")
 					fmt.Fprintln(file, "                </TR>")
-					fmt.Fprintf(file, "                <TR><TD ALIGN=\"LEFT\">Mount:</TD><TD ALIGN=\"LEFT\">%d</TD></TR>\n", currentPosition)
+					fmt.Fprintf(file, "                <TR><TD ALIGN=\"LEFT\">Mount:</TD><TD ALIGN=\"LEFT\">%c</TD></TR>\n", ebr.Mount)
 					fmt.Fprintf(file, "                <TR><TD ALIGN=\"LEFT\">Fit:</TD><TD ALIGN=\"LEFT\">%c</TD></TR>\n", ebr.Fit)
 					fmt.Fprintf(file, "                <TR><TD ALIGN=\"LEFT\">Start:</TD><TD ALIGN=\"LEFT\">%d bytes</TD></TR>\n", ebr.Start)
 					fmt.Fprintf(file, "                <TR><TD ALIGN=\"LEFT\">Size:</TD><TD ALIGN=\"LEFT\">%d bytes</TD></TR>\n", ebr.Size)
@@ -238,7 +243,7 @@ func Report_MBR_EBRs(path_disk string, path string) error {
 					fmt.Fprintln(file, "        }")
 
 					ebrIndex++
-					if ebr.Next == -1 {
+					if esFinCadenaEBR(ebr.Next) {
 						break
 					}
 
@@ -278,7 +283,19 @@ func renderDotFile(dotPath, outputPath string) error {
 	return nil
 }
 
-func generateDiskReport(mbr MBR, outputPath string, diskName string) error {
+// Lee las particiones lógicas de la extendida del MBR, si existe. Los errores en la
+// cadena se ignoran y se devuelven los EBRs leídos hasta ese punto.
+func leerEBRsDelMBR(file *os.File, mbr MBR) []EBR {
+	for _, partition := range mbr.Partitions {
+		if partition.PartStatus != 0 && partition.PartType == 'e' {
+			ebrs, _ := leerCadenaEBR(file, partition)
+			return ebrs
+		}
+	}
+	return nil
+}
+
+func generateDiskReport(mbr MBR, diskPath string, outputPath string, diskName string) error {
 	// Cambiar la extensión del outputPath a .dot
 	dotPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".dot"
 
@@ -305,6 +322,22 @@ func generateDiskReport(mbr MBR, outputPath string, diskName string) error {
 	freeSpace := mbr.Partitions[0].PartStart
 	fmt.Fprintf(file, "  mbr [label=\"MBR\\n%.2f%% del disco\", shape=box];\n", (float64(freeSpace)/float64(mbr.MbrTamano))*100)
 
+	// Particiones lógicas de la extendida y bytes perdidos por la alineación
+	var ebrs []EBR
+	var errEBRs error
+	if disk, cerrar, err := motor.abrirDisco(diskPath, os.O_RDONLY, 0); err == nil {
+		for _, partition := range mbr.Partitions {
+			if partition.PartStatus != 0 && partition.PartType == 'e' {
+				ebrs, errEBRs = leerCadenaEBR(disk, partition)
+				break
+			}
+		}
+		cerrar()
+	}
+	desperdicio := desperdicioAlineacion(mbr, ebrs)
+	fmt.Fprintf(file, "  alineacion [label=\"Alineación: %d bytes\\nDesperdicio: %d bytes (%.2f%% del disco)\", shape=note];\n",
+		alineacionMBR(mbr), desperdicio, (float64(desperdicio)/float64(mbr.MbrTamano))*100)
+
 	for i, partition := range mbr.Partitions {
 		if partition.PartStatus == 0 {
 			continue
@@ -316,10 +349,7 @@ func generateDiskReport(mbr MBR, outputPath string, diskName string) error {
 		if partition.PartType == 'p' {
 			fmt.Fprintf(file, "  primary%d [label=\"%s\\n%.2f%% del disco\", shape=box];\n", i, partitionName, percentage)
 		} else if partition.PartType == 'e' {
-			fmt.Fprintf(file, "  subgraph cluster_extended%d {\n", i)
-			fmt.Fprintf(file, "    label=\"Extendida\";\n")
-			// Aquí se leerán los EBRs si es necesario
-			fmt.Fprintln(file, "  }")
+			escribirExtendidaDot(file, i, partition, ebrs, errEBRs, mbr.MbrTamano)
 		}
 	}
 
@@ -330,6 +360,35 @@ func generateDiskReport(mbr MBR, outputPath string, diskName string) error {
 	return renderDotFile(dotPath, outputPath)
 }
 
+// Escribe el cluster de la partición extendida con cada EBR, su partición lógica y
+// los espacios libres entre ellas. Si la cadena de EBRs tiene errores se dibujan los
+// EBRs leídos hasta el error y una nota con el error.
+func escribirExtendidaDot(w io.Writer, i int, extendida Partition1, ebrs []EBR, errEBRs error, tamanoDisco int64) {
+	porcentaje := func(bytes int64) float64 {
+		return (float64(bytes) / float64(tamanoDisco)) * 100
+	}
+	sizeEBR := int64(binary.Size(EBR{}))
+
+	fmt.Fprintf(w, "  subgraph cluster_extended%d {\n", i)
+	fmt.Fprintf(w, "    label=\"Extendida\\n%.2f%% del disco\";\n", porcentaje(extendida.PartS))
+	pos := extendida.PartStart
+	for j, ebr := range ebrs {
+		if ebr.Start > pos {
+			fmt.Fprintf(w, "    libre%d_%d [label=\"Libre\\n%.2f%% del disco\"];\n", i, j, porcentaje(ebr.Start-pos))
+		}
+		nombre := strings.Trim(string(ebr.Name[:]), "\x00")
+		fmt.Fprintf(w, "    ebr%d_%d [label=\"EBR\"];\n", i, j)
+		fmt.Fprintf(w, "    logica%d_%d [label=\"Lógica\\n%s\\n%.2f%% del disco\"];\n", i, j, nombre, porcentaje(ebr.Size-sizeEBR))
+		pos = ebr.Start + ebr.Size
+	}
+	if errEBRs != nil {
+		fmt.Fprintf(w, "    error%d [label=\"%s\", shape=note];\n", i, errEBRs.Error())
+	} else if fin := extendida.PartStart + extendida.PartS; fin > pos {
+		fmt.Fprintf(w, "    libre%d_%d [label=\"Libre\\n%.2f%% del disco\"];\n", i, len(ebrs), porcentaje(fin-pos))
+	}
+	fmt.Fprintln(w, "  }")
+}
+
 /*---------------------------Reporte SB---------------------------------*/
 
 func imprimirSuperBloque(file *os.File, start int64) {
@@ -365,17 +424,17 @@ func imprimirSuperBloque(file *os.File, start int64) {
 
 func LeerSuperBloquePorID(id string) (*SuperBlock, error) {
 	// Verificar si la partición está montada usando el ID
-	partition, exists := mountedPartitions[id]
+	partition, exists := motor.montaje(id)
 	if !exists {
 		return nil, fmt.Errorf("partición con ID '%s' no está montada", id)
 	}
 
 	// Abrir el archivo del disco en modo de lectura
-	file, err := os.Open(partition.Path)
+	file, cerrar, err := motor.abrirDisco(partition.Path, os.O_RDONLY, 0)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir el archivo: %v", err)
 	}
-	defer file.Close()
+	defer cerrar()
 
 	// Determinar la posición del SuperBlock basado en la ubicación de la partición
 	offset := partition.Partition.PartStart // Ajusta esta línea si la posición del super bloque es diferente
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtendidaDotConLogicasYEspaciosLibres(t *testing.T) {
+	extendida := Partition1{PartStatus: '0', PartType: 'e', PartStart: 1000, PartS: 10000}
+	ebrs := []EBR{{Start: 1000, Size: 2000, Next: 5000}, {Start: 5000, Size: 1000, Next: -1}}
+	copy(ebrs[0].Name[:], "l1")
+	copy(ebrs[1].Name[:], "l2")
+
+	var dot strings.Builder
+	escribirExtendidaDot(&dot, 2, extendida, ebrs, nil, 20000)
+	salida := dot.String()
+	for _, esperado := range []string{
+		`label="Extendida\n50.00% del disco"`,
+		`ebr2_0 [label="EBR"]`,
+		`logica2_0 [label="Lógica\nl1\n`,
+		`libre2_1 [label="Libre\n10.00% del disco"]`,
+		`logica2_1 [label="Lógica\nl2\n`,
+		`libre2_2 [label="Libre\n25.00% del disco"]`,
+	} {
+		if !strings.Contains(salida, esperado) {
+			t.Errorf("falta %q en el cluster:\n%s", esperado, salida)
+		}
+	}
+}
+
+// Con un ciclo en la cadena se dibujan los EBRs leídos y una nota con el error
+func TestExtendidaDotConCicloEnLaCadena(t *testing.T) {
+	path, ebrs := prepararDiscoFsck(t)
+	modificarEBR(t, path, ebrs[1].Start, func(ebr *EBR) { ebr.Next = ebrs[0].Start })
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extendida := mbr.Partitions[2]
+	leidos, errEBRs := leerCadenaEBR(file, extendida)
+	if errEBRs == nil {
+		t.Fatal("leerCadenaEBR no detectó el ciclo")
+	}
+
+	var dot strings.Builder
+	escribirExtendidaDot(&dot, 2, extendida, leidos, errEBRs, mbr.MbrTamano)
+	salida := dot.String()
+	if !strings.Contains(salida, `Lógica\nl1`) || !strings.Contains(salida, `Lógica\nl2`) || !strings.Contains(salida, "ciclo") {
+		t.Errorf("cluster con un ciclo en la cadena:\n%s", salida)
+	}
+}
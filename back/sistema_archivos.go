@@ -0,0 +1,912 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Funciones para leer y escribir las estructuras del sistema de archivos de una
+// partición formateada: SuperBlock, bitmaps, tabla de inodos y bloques.
+
+const (
+	inodoRaiz     = 0 // Inodo de la carpeta raíz "/"
+	inodoUsuarios = 1 // Inodo del archivo /users.txt
+	sinBloque     = -1
+	uidRoot       = 1 // UID del usuario root en users.txt
+
+	apuntadoresDirectos = 12 // Apuntadores directos de Inode.Blocks; los 3 siguientes son indirectos
+)
+
+// Fecha actual con el formato de las estructuras del disco
+func fechaActual() [19]byte {
+	var fecha [19]byte
+	copy(fecha[:], time.Now().Format("2006-01-02 15:04:05"))
+	return fecha
+}
+
+// Crea un inodo vacío con todos los apuntadores libres
+func nuevoInodo(uid, gid int32, tipo byte, permisos string) Inode {
+	inodo := Inode{
+		UID:  uid,
+		GID:  gid,
+		Type: tipo,
+	}
+	fecha := fechaActual()
+	inodo.ATIME = fecha
+	inodo.CTIME = fecha
+	inodo.MTIME = fecha
+	for i := range inodo.Blocks {
+		inodo.Blocks[i] = sinBloque
+	}
+	copy(inodo.Perms[:], permisos)
+	return inodo
+}
+
+// Crea un bloque de carpeta con las entradas libres
+func nuevoFolderBlock() FolderBlock {
+	var bloque FolderBlock
+	for i := range bloque.B_content {
+		bloque.B_content[i].Inode = sinBloque
+	}
+	return bloque
+}
+
+func leerSuperBloque(file *os.File, start int64) (*SuperBlock, error) {
+	var superblock SuperBlock
+	if _, err := file.Seek(start, 0); err != nil {
+		return nil, fmt.Errorf("Error al posicionarse en el super bloque: %v", err)
+	}
+	if err := binary.Read(file, binary.LittleEndian, &superblock); err != nil {
+		return nil, fmt.Errorf("Error al leer el super bloque: %v", err)
+	}
+	if superblock.Magic != 0xEF53 {
+		return nil, fmt.Errorf("la partición no tiene un sistema de archivos")
+	}
+	return &superblock, nil
+}
+
+func escribirSuperBloque(file *os.File, start int64, superblock *SuperBlock) error {
+	if _, err := file.Seek(start, 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el super bloque: %v", err)
+	}
+	if err := binary.Write(file, binary.LittleEndian, superblock); err != nil {
+		return fmt.Errorf("Error al escribir el super bloque: %v", err)
+	}
+	return nil
+}
+
+// Tamaño en bytes de un inodo en el disco: UID, GID, tamaño, tres fechas, 15
+// apuntadores, tipo y permisos
+const tamanoInodo = 4 + 4 + 8 + 3*19 + 15*4 + 1 + 3
+
+// Convierte el inodo a su formato en el disco (little endian, sin relleno)
+func codificarInodo(inodo *Inode) []byte {
+	data := make([]byte, tamanoInodo)
+	pos := 0
+	binary.LittleEndian.PutUint32(data[pos:], uint32(inodo.UID))
+	pos += 4
+	binary.LittleEndian.PutUint32(data[pos:], uint32(inodo.GID))
+	pos += 4
+	binary.LittleEndian.PutUint64(data[pos:], uint64(inodo.Size))
+	pos += 8
+	for _, fecha := range [][19]byte{inodo.ATIME, inodo.CTIME, inodo.MTIME} {
+		pos += copy(data[pos:], fecha[:])
+	}
+	for _, apuntador := range inodo.Blocks {
+		binary.LittleEndian.PutUint32(data[pos:], uint32(apuntador))
+		pos += 4
+	}
+	data[pos] = inodo.Type
+	pos++
+	copy(data[pos:], inodo.Perms[:])
+	return data
+}
+
+// Reconstruye un inodo a partir de los tamanoInodo bytes leídos del disco
+func decodificarInodo(data []byte) (*Inode, error) {
+	if len(data) < tamanoInodo {
+		return nil, fmt.Errorf("inodo incompleto: %d de %d bytes", len(data), tamanoInodo)
+	}
+	var inodo Inode
+	pos := 0
+	inodo.UID = int32(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+	inodo.GID = int32(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+	inodo.Size = int64(binary.LittleEndian.Uint64(data[pos:]))
+	pos += 8
+	for _, fecha := range []*[19]byte{&inodo.ATIME, &inodo.CTIME, &inodo.MTIME} {
+		pos += copy(fecha[:], data[pos:pos+19])
+	}
+	for i := range inodo.Blocks {
+		inodo.Blocks[i] = int32(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+	}
+	inodo.Type = data[pos]
+	pos++
+	copy(inodo.Perms[:], data[pos:pos+3])
+	return &inodo, nil
+}
+
+func leerInodo(file *os.File, superblock *SuperBlock, indice int32) (*Inode, error) {
+	if indice < 0 || indice >= superblock.InodesCount {
+		return nil, fmt.Errorf("inodo %d fuera de la tabla de inodos", indice)
+	}
+	data := make([]byte, tamanoInodo)
+	if _, err := file.ReadAt(data, int64(superblock.InodeStart)+int64(indice)*int64(superblock.InodeSize)); err != nil {
+		return nil, fmt.Errorf("Error al leer el inodo %d: %v", indice, err)
+	}
+	return decodificarInodo(data)
+}
+
+func escribirInodo(file *os.File, superblock *SuperBlock, indice int32, inodo *Inode) error {
+	if indice < 0 || indice >= superblock.InodesCount {
+		return fmt.Errorf("inodo %d fuera de la tabla de inodos", indice)
+	}
+	if _, err := file.WriteAt(codificarInodo(inodo), int64(superblock.InodeStart)+int64(indice)*int64(superblock.InodeSize)); err != nil {
+		return fmt.Errorf("Error al escribir el inodo %d: %v", indice, err)
+	}
+	return nil
+}
+
+// Lee el bloque indicado en bloque, que debe ser un puntero a FolderBlock o Fileblock
+func leerBloque(file *os.File, superblock *SuperBlock, indice int32, bloque interface{}) error {
+	if indice < 0 || indice >= superblock.BlocksCount {
+		return fmt.Errorf("bloque %d fuera de la tabla de bloques", indice)
+	}
+	if _, err := file.Seek(int64(superblock.BlockStart)+int64(indice)*int64(superblock.BlockSize), 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el bloque %d: %v", indice, err)
+	}
+	if err := binary.Read(file, binary.LittleEndian, bloque); err != nil {
+		return fmt.Errorf("Error al leer el bloque %d: %v", indice, err)
+	}
+	return nil
+}
+
+func escribirBloque(file *os.File, superblock *SuperBlock, indice int32, bloque interface{}) error {
+	if indice < 0 || indice >= superblock.BlocksCount {
+		return fmt.Errorf("bloque %d fuera de la tabla de bloques", indice)
+	}
+	if _, err := file.Seek(int64(superblock.BlockStart)+int64(indice)*int64(superblock.BlockSize), 0); err != nil {
+		return fmt.Errorf("Error al posicionarse en el bloque %d: %v", indice, err)
+	}
+	if err := binary.Write(file, binary.LittleEndian, bloque); err != nil {
+		return fmt.Errorf("Error al escribir el bloque %d: %v", indice, err)
+	}
+	return nil
+}
+
+// Marca una posición de un bitmap como usada (1) o libre (0)
+func marcarBitmap(file *os.File, inicio int32, indice int32, usado bool) error {
+	var valor byte
+	if usado {
+		valor = 1
+	}
+	if _, err := file.WriteAt([]byte{valor}, int64(inicio)+int64(indice)); err != nil {
+		return fmt.Errorf("Error al escribir el bitmap: %v", err)
+	}
+	return nil
+}
+
+// Escribe ceros en el rango [inicio, inicio+tamano) del disco por partes de hasta
+// 1 MB y de a lo sumo la décima parte del rango, para que el avance se informe al
+// menos cada 10%. Si progreso no es nil se llama después de cada parte con los
+// bytes escritos.
+func escribirCeros(file *os.File, inicio, tamano int64, progreso func(escrito, total int64)) error {
+	parte := int64(1024 * 1024)
+	if decima := tamano / 10; decima > 0 && decima < parte {
+		parte = decima
+	}
+	ceros := make([]byte, parte)
+	for escrito := int64(0); escrito < tamano; {
+		n := int64(len(ceros))
+		if tamano-escrito < n {
+			n = tamano - escrito
+		}
+		if _, err := file.WriteAt(ceros[:n], inicio+escrito); err != nil {
+			return fmt.Errorf("Error al limpiar el disco en el byte %d: %v", inicio+escrito, err)
+		}
+		escrito += n
+		if progreso != nil {
+			progreso(escrito, tamano)
+		}
+	}
+	return nil
+}
+
+// ----------------------------------SISTEMA-ARCHIVOS---------------------------------
+
+// Partición con sistema de archivos abierta para ejecutar un comando sobre ella. Se
+// obtiene con abrirSistemaArchivos, que mantiene el disco bloqueado mientras se usa.
+type sistemaArchivos struct {
+	file       *os.File
+	superblock *SuperBlock
+	inicio     int64  // Inicio de la partición, donde está el SuperBlock
+	sesion     Sesion // Usuario que ejecuta el comando
+	// Inodos y bloques reservados por la operación en curso que siguen ocupados;
+	// modificarSistemaArchivos los libera si la operación falla
+	reservados map[reserva]bool
+}
+
+// Posición reservada en el bitmap que inicia en Bitmap
+type reserva struct {
+	Bitmap int32
+	Indice int32
+}
+
+// Entrada de un bloque de carpeta
+type entradaCarpeta struct {
+	Nombre string
+	Inodo  int32
+	Bloque int32 // Bloque de carpeta donde está la entrada
+	Indice int   // Posición de la entrada dentro del bloque
+}
+
+// Abre la partición de la sesión activa. Con escritura se verifica que la partición
+// no esté montada en solo lectura y el disco se bloquea para escritura. La función
+// devuelta libera el disco; no escribe el SuperBlock (ver guardarSuperBloque).
+func abrirSistemaArchivos(escritura bool) (*sistemaArchivos, func(), error) {
+	sesion := motor.sesionActual()
+	if !sesion.Activa {
+		return nil, nil, ErrSinSesion
+	}
+	partition, exists := motor.montaje(sesion.Particion)
+	if !exists {
+		return nil, nil, fmt.Errorf("partición con ID '%s' no está montada", sesion.Particion)
+	}
+
+	flag := os.O_RDONLY
+	if escritura {
+		if err := verificarEscritura(sesion.Particion); err != nil {
+			return nil, nil, err
+		}
+		flag = os.O_RDWR
+	}
+	file, cerrar, err := motor.abrirDisco(partition.Path, flag, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error al abrir el disco: %v", err)
+	}
+	superblock, err := leerSuperBloque(file, partition.Partition.PartStart)
+	if err != nil {
+		cerrar()
+		return nil, nil, err
+	}
+	return &sistemaArchivos{file: file, superblock: superblock, inicio: partition.Partition.PartStart, sesion: sesion}, cerrar, nil
+}
+
+func (fs *sistemaArchivos) guardarSuperBloque() error {
+	return escribirSuperBloque(fs.file, fs.inicio, fs.superblock)
+}
+
+// Ejecuta la operación y la registra en el journal si la partición es EXT3. El
+// dueño de la operación se agrega a los parámetros para poder repetirla con
+// recovery. La entrada se valida antes de ejecutar la operación, así un cambio que
+// no se puede registrar no se aplica.
+func (fs *sistemaArchivos) conJournal(operacion, path, contenido string, cambiar func() error) error {
+	return fs.conJournalDatos(operacion, path, contenido, nil, cambiar)
+}
+
+// Como conJournal, pero después de la entrada de la operación guarda datos, el
+// contenido del archivo, en entradas "datos" (ver entradasDatosJournal). Si no hay
+// entradas libres para todas, la operación se rechaza sin aplicarse.
+func (fs *sistemaArchivos) conJournalDatos(operacion, path, contenido string, datos []byte, cambiar func() error) error {
+	if fs.superblock.FilesystemType != 3 {
+		return cambiar()
+	}
+	contenido = fmt.Sprintf("%s -uid=%d -gid=%d", contenido, fs.sesion.ID, fs.sesion.GID)
+	entrada, err := nuevaEntradaJournal(operacion, path, contenido)
+	if err != nil {
+		return err
+	}
+	entradas := append([]Journal{entrada}, entradasDatosJournal(path, datos)...)
+	libre, err := entradaLibreJournal(fs.file, fs.superblock, fs.inicio)
+	if err != nil {
+		return err
+	}
+	if disponibles := fs.superblock.InodesCount - libre; int32(len(entradas)) > disponibles {
+		return fmt.Errorf("%w: la operación necesita %d entradas y quedan %d", ErrJournalLleno, len(entradas), disponibles)
+	}
+	if err := cambiar(); err != nil {
+		return err
+	}
+	for i, entrada := range entradas {
+		if err := escribirEntradaJournal(fs.file, fs.inicio, libre+int32(i), entrada); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *sistemaArchivos) leerInodo(indice int32) (*Inode, error) {
+	return leerInodo(fs.file, fs.superblock, indice)
+}
+
+func (fs *sistemaArchivos) escribirInodo(indice int32, inodo *Inode) error {
+	return escribirInodo(fs.file, fs.superblock, indice, inodo)
+}
+
+// Reserva el primer inodo o bloque libre del bitmap y actualiza los contadores del
+// SuperBlock
+func (fs *sistemaArchivos) reservar(inicioBitmap, total int32, primero, libres *int32, nombre string) (int32, error) {
+	if *libres <= 0 {
+		return -1, fmt.Errorf("no hay %s libres en la partición", nombre)
+	}
+	bitmap := make([]byte, total)
+	if _, err := fs.file.ReadAt(bitmap, int64(inicioBitmap)); err != nil {
+		return -1, fmt.Errorf("Error al leer el bitmap de %s: %v", nombre, err)
+	}
+	for i := int32(0); i < total; i++ {
+		if bitmap[i] != 0 {
+			continue
+		}
+		if err := marcarBitmap(fs.file, inicioBitmap, i, true); err != nil {
+			return -1, err
+		}
+		if fs.reservados == nil {
+			fs.reservados = make(map[reserva]bool)
+		}
+		fs.reservados[reserva{inicioBitmap, i}] = true
+		*libres--
+		// El siguiente libre queda como el primero disponible
+		*primero = -1
+		for j := i + 1; j < total; j++ {
+			if bitmap[j] == 0 {
+				*primero = j
+				break
+			}
+		}
+		return i, nil
+	}
+	return -1, fmt.Errorf("no hay %s libres en la partición", nombre)
+}
+
+func (fs *sistemaArchivos) reservarInodo() (int32, error) {
+	sb := fs.superblock
+	return fs.reservar(sb.BmInodeStart, sb.InodesCount, &sb.FirstInode, &sb.FreeInodesCount, "inodos")
+}
+
+func (fs *sistemaArchivos) reservarBloque() (int32, error) {
+	sb := fs.superblock
+	return fs.reservar(sb.BmBlockStart, sb.BlocksCount, &sb.FirstBlock, &sb.FreeBlocksCount, "bloques")
+}
+
+// Marca como libre la posición del bitmap y actualiza el contador de libres y el
+// primero disponible del SuperBlock
+func (fs *sistemaArchivos) liberar(inicioBitmap, indice int32, primero, libres *int32) error {
+	if err := marcarBitmap(fs.file, inicioBitmap, indice, false); err != nil {
+		return err
+	}
+	delete(fs.reservados, reserva{inicioBitmap, indice})
+	*libres++
+	if *primero == -1 || indice < *primero {
+		*primero = indice
+	}
+	return nil
+}
+
+func (fs *sistemaArchivos) liberarInodo(indice int32) error {
+	sb := fs.superblock
+	return fs.liberar(sb.BmInodeStart, indice, &sb.FirstInode, &sb.FreeInodesCount)
+}
+
+func (fs *sistemaArchivos) liberarBloque(indice int32) error {
+	sb := fs.superblock
+	return fs.liberar(sb.BmBlockStart, indice, &sb.FirstBlock, &sb.FreeBlocksCount)
+}
+
+func nuevoPointerBlock() PointerBlock {
+	var bloque PointerBlock
+	for i := range bloque.B_pointers {
+		bloque.B_pointers[i] = sinBloque
+	}
+	return bloque
+}
+
+// Nivel de indirección de cada apuntador del inodo: 0 para los 12 directos, 1 para
+// el simple, 2 para el doble y 3 para el triple indirecto
+func nivelApuntador(i int) int {
+	if i < apuntadoresDirectos {
+		return 0
+	}
+	return i - apuntadoresDirectos + 1
+}
+
+// Cantidad de bloques (de datos y de apuntadores) que ocupa un inodo nuevo con
+// datos bloques de datos, o -1 si no caben en sus apuntadores
+func bloquesNecesarios(datos int) int {
+	apuntadores := len(PointerBlock{}.B_pointers)
+	total := datos
+	restantes := datos - apuntadoresDirectos
+	alcance := 1 // Bloques de datos que cubre cada apuntador del nivel actual
+	for nivel := 1; nivel <= 3 && restantes > 0; nivel++ {
+		alcance *= apuntadores
+		cubiertos := restantes
+		if cubiertos > alcance {
+			cubiertos = alcance
+		}
+		// Bloques de apuntadores de cada nivel del árbol, de la raíz a las hojas
+		for cubre := alcance / apuntadores; cubre >= 1; cubre /= apuntadores {
+			total += (cubiertos + cubre*apuntadores - 1) / (cubre * apuntadores)
+		}
+		restantes -= cubiertos
+	}
+	if restantes > 0 {
+		return -1
+	}
+	return total
+}
+
+// Recorre los bloques del inodo en orden. visitar recibe cada bloque ocupado y si
+// es un bloque de apuntadores; los bloques de apuntadores se visitan después de
+// los bloques a los que apuntan.
+func (fs *sistemaArchivos) recorrerBloques(inodo *Inode, visitar func(bloque int32, apuntador bool) error) error {
+	for i, bloque := range inodo.Blocks {
+		if bloque == sinBloque {
+			continue
+		}
+		if err := fs.recorrerApuntador(bloque, nivelApuntador(i), visitar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *sistemaArchivos) recorrerApuntador(bloque int32, nivel int, visitar func(bloque int32, apuntador bool) error) error {
+	if nivel == 0 {
+		return visitar(bloque, false)
+	}
+	var apuntadores PointerBlock
+	if err := leerBloque(fs.file, fs.superblock, bloque, &apuntadores); err != nil {
+		return err
+	}
+	for _, siguiente := range apuntadores.B_pointers {
+		if siguiente == sinBloque {
+			continue
+		}
+		if err := fs.recorrerApuntador(siguiente, nivel-1, visitar); err != nil {
+			return err
+		}
+	}
+	return visitar(bloque, true)
+}
+
+// Bloques de datos del inodo en orden, incluidos los de los apuntadores indirectos
+func (fs *sistemaArchivos) bloquesDatos(inodo *Inode) ([]int32, error) {
+	var bloques []int32
+	err := fs.recorrerBloques(inodo, func(bloque int32, apuntador bool) error {
+		if !apuntador {
+			bloques = append(bloques, bloque)
+		}
+		return nil
+	})
+	return bloques, err
+}
+
+// Libera todos los bloques del inodo, incluidos los de apuntadores, y lo deja vacío.
+// El inodo se modifica en memoria; quien llama debe escribirlo.
+func (fs *sistemaArchivos) liberarBloques(inodo *Inode) error {
+	err := fs.recorrerBloques(inodo, func(bloque int32, apuntador bool) error {
+		return fs.liberarBloque(bloque)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range inodo.Blocks {
+		inodo.Blocks[i] = sinBloque
+	}
+	inodo.Size = 0
+	return nil
+}
+
+// Cantidad de bloques que ocupa el inodo, incluidos los de apuntadores
+func (fs *sistemaArchivos) contarBloques(inodo *Inode) (int, error) {
+	total := 0
+	err := fs.recorrerBloques(inodo, func(bloque int32, apuntador bool) error {
+		total++
+		return nil
+	})
+	return total, err
+}
+
+// Reserva un bloque de datos y lo agrega al final de los bloques del inodo, creando
+// los bloques de apuntadores indirectos que hagan falta. El inodo se modifica en
+// memoria; quien llama debe escribirlo.
+func (fs *sistemaArchivos) agregarBloque(inodo *Inode) (int32, error) {
+	for i := range inodo.Blocks {
+		nivel := nivelApuntador(i)
+		if nivel == 0 {
+			if inodo.Blocks[i] != sinBloque {
+				continue
+			}
+			bloque, err := fs.reservarBloque()
+			if err != nil {
+				return -1, err
+			}
+			inodo.Blocks[i] = bloque
+			return bloque, nil
+		}
+
+		if inodo.Blocks[i] == sinBloque {
+			apuntadores, err := fs.nuevoBloqueApuntadores()
+			if err != nil {
+				return -1, err
+			}
+			inodo.Blocks[i] = apuntadores
+		}
+		bloque, agregado, err := fs.agregarEnApuntador(inodo.Blocks[i], nivel)
+		if err != nil || agregado {
+			return bloque, err
+		}
+	}
+	return -1, fmt.Errorf("el inodo no tiene apuntadores libres")
+}
+
+// Agrega un bloque de datos al árbol de apuntadores de nivel indicado. Los bloques
+// se llenan en orden, así que solo se revisa el último apuntador ocupado de cada
+// nivel. Devuelve falso si el árbol está lleno.
+func (fs *sistemaArchivos) agregarEnApuntador(bloqueApuntadores int32, nivel int) (int32, bool, error) {
+	var apuntadores PointerBlock
+	if err := leerBloque(fs.file, fs.superblock, bloqueApuntadores, &apuntadores); err != nil {
+		return -1, false, err
+	}
+	ultimo := -1
+	for j, siguiente := range apuntadores.B_pointers {
+		if siguiente != sinBloque {
+			ultimo = j
+		}
+	}
+	if nivel > 1 && ultimo >= 0 {
+		bloque, agregado, err := fs.agregarEnApuntador(apuntadores.B_pointers[ultimo], nivel-1)
+		if err != nil || agregado {
+			return bloque, agregado, err
+		}
+	}
+	if ultimo == len(apuntadores.B_pointers)-1 {
+		return -1, false, nil
+	}
+
+	var nuevo int32
+	var err error
+	if nivel == 1 {
+		nuevo, err = fs.reservarBloque()
+	} else {
+		nuevo, err = fs.nuevoBloqueApuntadores()
+	}
+	if err != nil {
+		return -1, false, err
+	}
+	apuntadores.B_pointers[ultimo+1] = nuevo
+	if err := escribirBloque(fs.file, fs.superblock, bloqueApuntadores, &apuntadores); err != nil {
+		return -1, false, err
+	}
+	if nivel == 1 {
+		return nuevo, true, nil
+	}
+	return fs.agregarEnApuntador(nuevo, nivel-1)
+}
+
+// Reserva un bloque de apuntadores vacío
+func (fs *sistemaArchivos) nuevoBloqueApuntadores() (int32, error) {
+	bloque, err := fs.reservarBloque()
+	if err != nil {
+		return -1, err
+	}
+	apuntadores := nuevoPointerBlock()
+	if err := escribirBloque(fs.file, fs.superblock, bloque, &apuntadores); err != nil {
+		return -1, err
+	}
+	return bloque, nil
+}
+
+// Entradas ocupadas de una carpeta, incluidas "." y ".."
+func (fs *sistemaArchivos) entradasCarpeta(carpeta *Inode) ([]entradaCarpeta, error) {
+	if carpeta.Type != DirType {
+		return nil, fmt.Errorf("el inodo no es una carpeta")
+	}
+	bloques, err := fs.bloquesDatos(carpeta)
+	if err != nil {
+		return nil, err
+	}
+	var entradas []entradaCarpeta
+	for _, indice := range bloques {
+		var bloque FolderBlock
+		if err := leerBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return nil, err
+		}
+		for i, contenido := range bloque.B_content {
+			if contenido.Inode == sinBloque || contenido.Name[0] == 0 {
+				continue
+			}
+			entradas = append(entradas, entradaCarpeta{
+				Nombre: strings.TrimRight(string(contenido.Name[:]), "\x00"),
+				Inodo:  contenido.Inode,
+				Bloque: indice,
+				Indice: i,
+			})
+		}
+	}
+	return entradas, nil
+}
+
+// Busca un nombre dentro de una carpeta. Devuelve -1 si no existe.
+func (fs *sistemaArchivos) buscarEnCarpeta(carpeta *Inode, nombre string) (int32, error) {
+	entrada, err := fs.buscarEntrada(carpeta, nombre)
+	if err != nil || entrada == nil {
+		return -1, err
+	}
+	return entrada.Inodo, nil
+}
+
+// Busca la entrada de un nombre dentro de una carpeta. Devuelve nil si no existe.
+func (fs *sistemaArchivos) buscarEntrada(carpeta *Inode, nombre string) (*entradaCarpeta, error) {
+	entradas, err := fs.entradasCarpeta(carpeta)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entradas {
+		if entradas[i].Nombre == nombre {
+			return &entradas[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Reemplaza el contenido de una entrada en su bloque de carpeta
+func (fs *sistemaArchivos) escribirEntrada(entrada *entradaCarpeta, contenido BlockContent) error {
+	var bloque FolderBlock
+	if err := leerBloque(fs.file, fs.superblock, entrada.Bloque, &bloque); err != nil {
+		return err
+	}
+	bloque.B_content[entrada.Indice] = contenido
+	return escribirBloque(fs.file, fs.superblock, entrada.Bloque, &bloque)
+}
+
+// Quita la entrada de un nombre de la carpeta y escribe la carpeta con la fecha de
+// modificación
+func (fs *sistemaArchivos) quitarEntrada(indiceCarpeta int32, carpeta *Inode, nombre string) error {
+	entrada, err := fs.buscarEntrada(carpeta, nombre)
+	if err != nil {
+		return err
+	}
+	if entrada == nil {
+		return fmt.Errorf("no existe '%s' en la carpeta", nombre)
+	}
+	if err := fs.escribirEntrada(entrada, BlockContent{Inode: sinBloque}); err != nil {
+		return err
+	}
+	carpeta.MTIME = fechaActual()
+	return fs.escribirInodo(indiceCarpeta, carpeta)
+}
+
+// Busca el primer espacio libre en los bloques de la carpeta. Si no hay devuelve el
+// bloque -1 y la cantidad de bloques de datos de la carpeta.
+func (fs *sistemaArchivos) espacioLibreCarpeta(carpeta *Inode) (bloque int32, posicion int, datos int, err error) {
+	bloques, err := fs.bloquesDatos(carpeta)
+	if err != nil {
+		return -1, 0, 0, err
+	}
+	for _, indice := range bloques {
+		var contenido FolderBlock
+		if err := leerBloque(fs.file, fs.superblock, indice, &contenido); err != nil {
+			return -1, 0, 0, err
+		}
+		for i, entrada := range contenido.B_content {
+			if entrada.Inode == sinBloque || entrada.Name[0] == 0 {
+				return indice, i, len(bloques), nil
+			}
+		}
+	}
+	return -1, 0, len(bloques), nil
+}
+
+// Bloques que reserva agregarBloque en un inodo con datos bloques de datos: el
+// bloque nuevo más los de apuntadores que haga falta encadenar, o -1 si no cabe
+func bloquesAgregados(datos int) int {
+	siguiente := bloquesNecesarios(datos + 1)
+	if siguiente < 0 {
+		return -1
+	}
+	return siguiente - bloquesNecesarios(datos)
+}
+
+// Bloques que hay que reservar para agregar una entrada a la carpeta: 0 si tiene un
+// espacio libre o el bloque de carpeta nuevo más los de apuntadores
+func (fs *sistemaArchivos) bloquesNuevaEntrada(carpeta *Inode) (int, error) {
+	indice, _, datos, err := fs.espacioLibreCarpeta(carpeta)
+	if err != nil || indice != -1 {
+		return 0, err
+	}
+	agregados := bloquesAgregados(datos)
+	if agregados < 0 {
+		return 0, fmt.Errorf("la carpeta no admite más entradas")
+	}
+	return agregados, nil
+}
+
+// Agrega la entrada nombre -> inodo a la carpeta. Usa el primer espacio libre de sus
+// bloques; si los cuatro espacios de todos los bloques están ocupados encadena un
+// bloque de carpeta nuevo. La carpeta se escribe con la fecha de modificación.
+func (fs *sistemaArchivos) agregarEntrada(indiceCarpeta int32, carpeta *Inode, nombre string, inodo int32) error {
+	var nombreBytes [12]byte
+	copy(nombreBytes[:], nombre)
+
+	indice, posicion, _, err := fs.espacioLibreCarpeta(carpeta)
+	if err != nil {
+		return err
+	}
+	if indice != -1 {
+		var bloque FolderBlock
+		if err := leerBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return err
+		}
+		bloque.B_content[posicion] = BlockContent{Name: nombreBytes, Inode: inodo}
+		if err := escribirBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return err
+		}
+	} else {
+		// El espacio se verifica antes de reservar para no dejar bloques de
+		// apuntadores reservados si falta el bloque de carpeta
+		agregados, err := fs.bloquesNuevaEntrada(carpeta)
+		if err != nil {
+			return err
+		}
+		if int32(agregados) > fs.superblock.FreeBlocksCount {
+			return fmt.Errorf("no hay bloques libres para agregar '%s' a la carpeta", nombre)
+		}
+		if indice, err = fs.agregarBloque(carpeta); err != nil {
+			return err
+		}
+		bloque := nuevoFolderBlock()
+		bloque.B_content[0] = BlockContent{Name: nombreBytes, Inode: inodo}
+		if err := escribirBloque(fs.file, fs.superblock, indice, &bloque); err != nil {
+			return err
+		}
+		carpeta.MTIME = fechaActual()
+		if err := fs.escribirInodo(indiceCarpeta, carpeta); err != nil {
+			return err
+		}
+		// Los bloques nuevos ya son parte de la carpeta: aunque la operación falle
+		// y se quite la entrada, no se liberan con lo reservado
+		return fs.recorrerBloques(carpeta, func(bloque int32, _ bool) error {
+			delete(fs.reservados, reserva{fs.superblock.BmBlockStart, bloque})
+			return nil
+		})
+	}
+
+	carpeta.MTIME = fechaActual()
+	return fs.escribirInodo(indiceCarpeta, carpeta)
+}
+
+// Separa una ruta absoluta en sus nombres
+func dividirRuta(ruta string) ([]string, error) {
+	if !strings.HasPrefix(ruta, "/") {
+		return nil, fmt.Errorf("la ruta '%s' debe ser absoluta", ruta)
+	}
+	var nombres []string
+	for _, nombre := range strings.Split(ruta, "/") {
+		if nombre == "" {
+			continue
+		}
+		if nombre == "." || nombre == ".." {
+			return nil, fmt.Errorf("la ruta '%s' no puede contener '.' ni '..'", ruta)
+		}
+		if err := validarNombre(nombre); err != nil {
+			return nil, err
+		}
+		nombres = append(nombres, nombre)
+	}
+	return nombres, nil
+}
+
+// Verifica que el nombre se pueda guardar en una entrada de carpeta
+func validarNombre(nombre string) error {
+	if nombre == "" || nombre == "." || nombre == ".." || strings.Contains(nombre, "/") {
+		return fmt.Errorf("nombre inválido: '%s'", nombre)
+	}
+	// El journal guarda las rutas entre comillas
+	if strings.Contains(nombre, `"`) {
+		return fmt.Errorf("el nombre '%s' no puede contener comillas", nombre)
+	}
+	if len(nombre) > len(BlockContent{}.Name) {
+		return fmt.Errorf("el nombre '%s' excede %d caracteres", nombre, len(BlockContent{}.Name))
+	}
+	return nil
+}
+
+// Separa una ruta absoluta en la ruta de su carpeta padre y su nombre
+func separarRuta(ruta string) (string, string, error) {
+	nombres, err := dividirRuta(ruta)
+	if err != nil {
+		return "", "", err
+	}
+	if len(nombres) == 0 {
+		return "", "", fmt.Errorf("la ruta no puede ser la carpeta raíz")
+	}
+	return "/" + strings.Join(nombres[:len(nombres)-1], "/"), nombres[len(nombres)-1], nil
+}
+
+// Busca el inodo de una ruta absoluta partiendo de la carpeta raíz. Cada carpeta
+// que se atraviesa requiere el permiso de ejecución.
+func (fs *sistemaArchivos) resolverRuta(ruta string) (int32, *Inode, error) {
+	nombres, err := dividirRuta(ruta)
+	if err != nil {
+		return -1, nil, err
+	}
+	indice := int32(inodoRaiz)
+	inodo, err := fs.leerInodo(indice)
+	if err != nil {
+		return -1, nil, err
+	}
+	for i, nombre := range nombres {
+		if inodo.Type != DirType {
+			return -1, nil, fmt.Errorf("'/%s' no es una carpeta", strings.Join(nombres[:i], "/"))
+		}
+		if err := fs.autorizar(inodo, Exec, "/"+strings.Join(nombres[:i], "/")); err != nil {
+			return -1, nil, err
+		}
+		siguiente, err := fs.buscarEnCarpeta(inodo, nombre)
+		if err != nil {
+			return -1, nil, err
+		}
+		if siguiente == -1 {
+			return -1, nil, fmt.Errorf("no existe '/%s'", strings.Join(nombres[:i+1], "/"))
+		}
+		indice = siguiente
+		if inodo, err = fs.leerInodo(indice); err != nil {
+			return -1, nil, err
+		}
+	}
+	return indice, inodo, nil
+}
+
+// Ejecuta una operación de escritura sobre la partición de la sesión activa. Si la
+// operación falla, antes de guardar el SuperBlock se liberan los inodos y bloques
+// que dejó reservados, así un comando fallido no ocupa espacio en la partición.
+func modificarSistemaArchivos(operacion func(fs *sistemaArchivos) error) error {
+	fs, cerrar, err := abrirSistemaArchivos(true)
+	if err != nil {
+		return err
+	}
+	defer cerrar()
+
+	if err := operacion(fs); err != nil {
+		if errLiberar := fs.liberarReservados(); errLiberar != nil {
+			return fmt.Errorf("%w; además no se pudo liberar lo reservado: %v", err, errLiberar)
+		}
+		if errGuardar := fs.guardarSuperBloque(); errGuardar != nil {
+			return fmt.Errorf("%w; además no se pudo guardar el SuperBlock: %v", err, errGuardar)
+		}
+		return err
+	}
+	return fs.guardarSuperBloque()
+}
+
+// Libera los inodos y bloques que la operación reservó y siguen ocupados. Las
+// operaciones quitan de sus carpetas lo que crearon antes de fallar, así ninguna
+// entrada queda apuntando a lo liberado.
+func (fs *sistemaArchivos) liberarReservados() error {
+	sb := fs.superblock
+	for r := range fs.reservados {
+		if r.Bitmap == sb.BmInodeStart {
+			if err := fs.liberarInodo(r.Indice); err != nil {
+				return err
+			}
+		} else if err := fs.liberarBloque(r.Indice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ejecuta una operación de solo lectura sobre la partición de la sesión activa
+func consultarSistemaArchivos(operacion func(fs *sistemaArchivos) error) error {
+	fs, cerrar, err := abrirSistemaArchivos(false)
+	if err != nil {
+		return err
+	}
+	defer cerrar()
+	return operacion(fs)
+}
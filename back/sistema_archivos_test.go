@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Inodo con todos sus campos en valores distintos de cero
+func inodoCompleto(size int64, apuntador func(i int) int32) Inode {
+	inodo := Inode{
+		UID:  math.MaxInt32,
+		GID:  math.MinInt32,
+		Size: size,
+		Type: FileType,
+	}
+	copy(inodo.ATIME[:], "2024-01-02 03:04:05")
+	copy(inodo.CTIME[:], "1999-12-31 23:59:59")
+	for i := range inodo.MTIME {
+		inodo.MTIME[i] = 0xFF
+	}
+	for i := range inodo.Blocks {
+		inodo.Blocks[i] = apuntador(i)
+	}
+	copy(inodo.Perms[:], "764")
+	return inodo
+}
+
+func TestCodificarInodoIdaYVuelta(t *testing.T) {
+	casos := []struct {
+		nombre string
+		inodo  Inode
+	}{
+		{"size máximo", inodoCompleto(math.MaxInt64, func(i int) int32 { return math.MaxInt32 - int32(i) })},
+		{"size mínimo", inodoCompleto(math.MinInt64, func(i int) int32 { return math.MinInt32 + int32(i) })},
+		{"sin bloques", inodoCompleto(0, func(i int) int32 { return sinBloque })},
+		{"bloques alternados", inodoCompleto(-1, func(i int) int32 {
+			if i%2 == 0 {
+				return math.MaxInt32
+			}
+			return math.MinInt32
+		})},
+		{"inodo vacío", Inode{}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			data := codificarInodo(&caso.inodo)
+			if len(data) != tamanoInodo {
+				t.Fatalf("len(data) = %d, se esperaba %d", len(data), tamanoInodo)
+			}
+			inodo, err := decodificarInodo(data)
+			if err != nil {
+				t.Fatalf("decodificarInodo: %v", err)
+			}
+			if *inodo != caso.inodo {
+				t.Fatalf("el inodo decodificado no coincide:\n obtenido %+v\n esperado %+v", *inodo, caso.inodo)
+			}
+		})
+	}
+}
+
+func TestDecodificarInodoIncompleto(t *testing.T) {
+	inodo := inodoCompleto(1, func(i int) int32 { return int32(i) })
+	data := codificarInodo(&inodo)
+	for _, n := range []int{0, 1, tamanoInodo - 1} {
+		if _, err := decodificarInodo(data[:n]); err == nil {
+			t.Errorf("decodificarInodo con %d bytes no devolvió error", n)
+		}
+	}
+}
+
+func TestLeerYEscribirInodoEnDisco(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "inodos.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	superblock := &SuperBlock{InodesCount: 4, InodeSize: tamanoInodo, InodeStart: 10}
+
+	inodos := []Inode{
+		inodoCompleto(math.MaxInt64, func(i int) int32 { return int32(i) }),
+		inodoCompleto(42, func(i int) int32 { return sinBloque }),
+	}
+	for i := range inodos {
+		if err := escribirInodo(file, superblock, int32(i+2), &inodos[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range inodos {
+		inodo, err := leerInodo(file, superblock, int32(i+2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *inodo != inodos[i] {
+			t.Errorf("inodo %d:\n obtenido %+v\n esperado %+v", i+2, *inodo, inodos[i])
+		}
+	}
+	if err := escribirInodo(file, superblock, superblock.InodesCount, &inodos[0]); err == nil {
+		t.Error("escribirInodo fuera de la tabla no devolvió error")
+	}
+	if _, err := leerInodo(file, superblock, -1); err == nil {
+		t.Error("leerInodo con índice negativo no devolvió error")
+	}
+}
+
+// Formatea una partición de prueba e inicia sesión como root en ella
+func prepararSistemaArchivos(t *testing.T, tamano int64, fsType string) {
+	t.Helper()
+	particion := prepararParticion(t, "990a", tamano, 0)
+	if _, err := formatPartition(particion.ID, fsType, false); err != nil {
+		t.Fatal(err)
+	}
+	iniciarSesionPrueba(t, particion.ID)
+}
+
+func TestBloquesNecesarios(t *testing.T) {
+	casos := []struct{ datos, total int }{
+		{0, 0},
+		{1, 1},
+		{12, 12},
+		{13, 12 + 1 + 1},
+		{28, 28 + 1},
+		{29, 29 + 1 + 2},
+		{284, 284 + 1 + 17},
+		{285, 285 + 1 + 17 + 3},
+		{4380, 4380 + 1 + 17 + 273},
+		{4381, -1},
+	}
+	for _, caso := range casos {
+		if total := bloquesNecesarios(caso.datos); total != caso.total {
+			t.Errorf("bloquesNecesarios(%d) = %d, se esperaba %d", caso.datos, total, caso.total)
+		}
+	}
+}
+
+// Archivos que terminan justo en el límite de cada nivel de apuntadores y uno más
+// allá: se escriben, se leen byte por byte y se eliminan, y los bitmaps deben
+// volver a sus contadores iniciales
+func TestArchivosConApuntadoresIndirectos(t *testing.T) {
+	const tamanoBloque = 64
+	casos := []struct {
+		nombre  string
+		bloques int
+		extra   int
+	}{
+		{"12 directos", 12, 0},
+		{"primer bloque simple", 12, 1},
+		{"fin del simple", 12 + 16, 0},
+		{"primer bloque doble", 12 + 16, 1},
+		{"fin del doble", 12 + 16 + 256, 0},
+		{"primer bloque triple", 12 + 16 + 256, 1},
+		{"dentro del triple", 12 + 16 + 256 + 300, 5},
+		{"fin del triple", 12 + 16 + 256 + 4096, 0},
+	}
+	prepararSistemaArchivos(t, 1024*1024, "ext2")
+
+	for i, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			contenido := make([]byte, caso.bloques*tamanoBloque+caso.extra)
+			for j := range contenido {
+				contenido[j] = byte(j*7 + i)
+			}
+			ruta := fmt.Sprintf("/archivo%d", i)
+
+			err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+				inodosIniciales, bloquesIniciales := contarLibresBitmaps(t, fs)
+				if err := fs.crearArchivo(ruta, contenido, false); err != nil {
+					return err
+				}
+				verificarContadores(t, fs)
+
+				leido, err := fs.leerArchivo(ruta)
+				if err != nil {
+					return err
+				}
+				if !bytes.Equal(leido, contenido) {
+					t.Fatalf("el contenido leído (%d bytes) no coincide con el escrito (%d bytes)", len(leido), len(contenido))
+				}
+				_, archivo, err := fs.resolverRuta(ruta)
+				if err != nil {
+					return err
+				}
+				ocupados, err := fs.contarBloques(archivo)
+				if err != nil {
+					return err
+				}
+				datos := (len(contenido) + tamanoBloque - 1) / tamanoBloque
+				if ocupados != bloquesNecesarios(datos) {
+					t.Errorf("el archivo ocupa %d bloques, se esperaban %d", ocupados, bloquesNecesarios(datos))
+				}
+				_, bloquesConArchivo := contarLibresBitmaps(t, fs)
+				if int(bloquesIniciales-bloquesConArchivo) != ocupados {
+					t.Errorf("se reservaron %d bloques para un archivo de %d bloques", bloquesIniciales-bloquesConArchivo, ocupados)
+				}
+
+				if err := fs.eliminar(ruta); err != nil {
+					return err
+				}
+				verificarContadores(t, fs)
+				inodos, bloques := contarLibresBitmaps(t, fs)
+				if inodos != inodosIniciales || bloques != bloquesIniciales {
+					t.Errorf("después de eliminar quedan %d inodos y %d bloques libres, antes había %d y %d",
+						inodos, bloques, inodosIniciales, bloquesIniciales)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestArchivoMayorAlMaximo(t *testing.T) {
+	prepararSistemaArchivos(t, 1024*1024, "ext2")
+	contenido := make([]byte, (12+16+256+4096)*64+1)
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		inodos, bloques := contarLibresBitmaps(t, fs)
+		if err := fs.crearArchivo("/grande", contenido, false); err == nil {
+			t.Fatal("se creó un archivo mayor al tamaño máximo")
+		}
+		if i, b := contarLibresBitmaps(t, fs); i != inodos || b != bloques {
+			t.Errorf("el intento fallido cambió los bitmaps")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Una carpeta con suficientes entradas para usar los apuntadores simple y doble
+func TestCarpetaConApuntadoresIndirectos(t *testing.T) {
+	prepararSistemaArchivos(t, 1024*1024, "ext2")
+	// 4 entradas por bloque: 130 entradas más "." y ".." ocupan 33 bloques
+	const entradas = 130
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		inodosIniciales, bloquesIniciales := contarLibresBitmaps(t, fs)
+		if err := fs.crearCarpeta("/muchas", false); err != nil {
+			return err
+		}
+		for i := 0; i < entradas; i++ {
+			if err := fs.crearArchivo(fmt.Sprintf("/muchas/f%d", i), []byte{byte(i)}, false); err != nil {
+				return err
+			}
+		}
+		verificarContadores(t, fs)
+
+		_, carpeta, err := fs.resolverRuta("/muchas")
+		if err != nil {
+			return err
+		}
+		if carpeta.Blocks[apuntadoresDirectos+1] == sinBloque {
+			t.Error("la carpeta no llegó al apuntador doble indirecto")
+		}
+		for _, i := range []int{0, 47, 48, 111, 112, entradas - 1} {
+			contenido, err := fs.leerArchivo(fmt.Sprintf("/muchas/f%d", i))
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(contenido, []byte{byte(i)}) {
+				t.Errorf("contenido de f%d = %v", i, contenido)
+			}
+		}
+
+		if err := fs.eliminar("/muchas"); err != nil {
+			return err
+		}
+		verificarContadores(t, fs)
+		if inodos, bloques := contarLibresBitmaps(t, fs); inodos != inodosIniciales || bloques != bloquesIniciales {
+			t.Errorf("después de eliminar quedan %d inodos y %d bloques libres, antes había %d y %d",
+				inodos, bloques, inodosIniciales, bloquesIniciales)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Si la operación falla después de reservar inodos y bloques, se liberan antes de
+// guardar el SuperBlock
+func TestModificarSistemaArchivosLiberaLaReservaSiFalla(t *testing.T) {
+	prepararSistemaArchivos(t, 128*1024, "ext2")
+	inodos, bloques := libresPrueba(t)
+
+	err := modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		if _, err := fs.reservarInodo(); err != nil {
+			return err
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := fs.reservarBloque(); err != nil {
+				return err
+			}
+		}
+		raiz, err := fs.leerInodo(inodoRaiz)
+		if err != nil {
+			return err
+		}
+		// Se acaban los bloques a mitad del contenido
+		contenido := bytes.Repeat([]byte("a"), int(fs.superblock.FreeBlocksCount+1)*64)
+		_, err = fs.nuevoArchivo(inodoRaiz, raiz, "a.txt", contenido)
+		return err
+	})
+	if err == nil {
+		t.Fatal("la operación no informó la falta de bloques")
+	}
+	if i, b := libresPrueba(t); i != inodos || b != bloques {
+		t.Errorf("quedan %d inodos y %d bloques libres, antes había %d y %d", i, b, inodos, bloques)
+	}
+	if existeRuta(t, "/a.txt") {
+		t.Error("el archivo fallido quedó en la raíz")
+	}
+}
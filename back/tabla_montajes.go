@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directorio donde el servidor guarda su estado (tabla de montajes). Se puede
+// cambiar con la variable de entorno MIA_DATA_DIR.
+var directorioDatos = obtenerDirectorioDatos()
+
+const archivoTablaMontajes = "montajes.json"
+
+func obtenerDirectorioDatos() string {
+	if dir := os.Getenv("MIA_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return "data"
+}
+
+// Registro de una partición montada tal como se guarda en la tabla de montajes
+type registroMontaje struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	EBRStart int64  `json:"ebr_start,omitempty"`
+	Letter   string `json:"letter"`
+	Number   int32  `json:"number"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// Guarda las particiones montadas en la tabla de montajes del directorio de datos
+func guardarTablaMontajes() error {
+	// La copia se toma con el candado de la tabla para que la última escritura sea
+	// siempre la más reciente
+	motor.tablaMu.Lock()
+	defer motor.tablaMu.Unlock()
+
+	montajes := motor.listarMontajes()
+	registros := make([]registroMontaje, 0, len(montajes))
+	for _, partition := range montajes {
+		registros = append(registros, registroMontaje{
+			ID:       partition.ID,
+			Path:     partition.Path,
+			Name:     strings.Trim(string(partition.Partition.PartName[:]), "\x00"),
+			Type:     string(partition.Partition.PartType),
+			EBRStart: partition.EBRStart,
+			Letter:   string(partition.Letter),
+			Number:   partition.Number,
+			ReadOnly: partition.ReadOnly,
+		})
+	}
+	data, err := json.MarshalIndent(registros, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error al serializar la tabla de montajes: %v", err)
+	}
+	if err := os.MkdirAll(directorioDatos, os.ModePerm); err != nil {
+		return fmt.Errorf("error al crear el directorio de datos: %v", err)
+	}
+
+	// Escribir en un archivo temporal y renombrarlo para no dejar la tabla a medias
+	ruta := filepath.Join(directorioDatos, archivoTablaMontajes)
+	if err := os.WriteFile(ruta+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("error al escribir la tabla de montajes: %v", err)
+	}
+	return os.Rename(ruta+".tmp", ruta)
+}
+
+// Lee la tabla de montajes guardada y vuelve a montar cada partición cuyo disco
+// todavía exista y cuyo MBR (o EBR) confirme el montaje. Las entradas de discos que
+// ya no existen se descartan, y las marcas de montaje que quedaron en los discos de
+// la tabla sin una entrada válida se limpian. Devuelve un mensaje por cada cambio.
+func restaurarMontajes() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(directorioDatos, archivoTablaMontajes))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la tabla de montajes: %v", err)
+	}
+
+	var registros []registroMontaje
+	if err := json.Unmarshal(data, &registros); err != nil {
+		return nil, fmt.Errorf("error al decodificar la tabla de montajes: %v", err)
+	}
+
+	// Agrupar por disco para leer cada MBR una sola vez
+	porDisco := make(map[string][]registroMontaje)
+	var discos []string
+	for _, registro := range registros {
+		if _, existe := porDisco[registro.Path]; !existe {
+			discos = append(discos, registro.Path)
+		}
+		porDisco[registro.Path] = append(porDisco[registro.Path], registro)
+	}
+
+	var messages []string
+	for _, path := range discos {
+		msgs, err := restaurarMontajesDisco(path, porDisco[path])
+		messages = append(messages, msgs...)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("Disco %s: %s", path, err.Error()))
+		}
+	}
+
+	if err := guardarTablaMontajes(); err != nil {
+		return messages, err
+	}
+	return messages, nil
+}
+
+// Vuelve a montar las particiones de un disco y limpia sus marcas de montaje obsoletas.
+// Los discos que no aparecen en la tabla se limpian al montar una de sus particiones
+// (ver mountPartition).
+func restaurarMontajesDisco(path string, registros []registroMontaje) ([]string, error) {
+	var messages []string
+
+	file, cerrar, err := motor.abrirDisco(path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		for _, registro := range registros {
+			messages = append(messages, fmt.Sprintf("Montaje %s descartado: el disco %s ya no existe", registro.ID, path))
+		}
+		return messages, nil
+	}
+	if err != nil {
+		return messages, fmt.Errorf("error al abrir el archivo del disco: %v", err)
+	}
+	defer cerrar()
+
+	mbr, err := leerMBR(file)
+	if err != nil {
+		return messages, fmt.Errorf("error al leer el MBR: %v", err)
+	}
+
+	for _, registro := range registros {
+		var partition Partition1
+		var ebrStart int64
+		restaurado := false
+
+		if registro.Type == "l" {
+			ebr, err := readEBR(file, registro.EBRStart)
+			if err == nil && ebr.Mount == '1' && ebr.Size > 0 && strings.Trim(string(ebr.Name[:]), "\x00") == registro.Name {
+				partition = particionLogicaMontada(ebr, registro.ID, registro.Number)
+				ebrStart = registro.EBRStart
+				restaurado = true
+			}
+		} else {
+			for _, part := range mbr.Partitions {
+				if part.PartStatus == '1' &&
+					strings.ToLower(strings.Trim(string(part.PartId[:]), "\x00")) == registro.ID &&
+					strings.Trim(string(part.PartName[:]), "\x00") == registro.Name {
+					partition = part
+					restaurado = true
+					break
+				}
+			}
+		}
+
+		if !restaurado || len(registro.Letter) != 1 {
+			messages = append(messages, fmt.Sprintf("Montaje %s descartado: la partición '%s' ya no está montada en %s", registro.ID, registro.Name, path))
+			continue
+		}
+		agregado := motor.agregarMontaje(MountedPartition{
+			ID:        registro.ID,
+			Path:      path,
+			Partition: partition,
+			EBRStart:  ebrStart,
+			Letter:    registro.Letter[0],
+			Number:    registro.Number,
+			ReadOnly:  registro.ReadOnly,
+		})
+		if !agregado {
+			messages = append(messages, fmt.Sprintf("Montaje %s descartado: el ID ya está en uso", registro.ID))
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("Partición '%s' montada de nuevo con ID %s", registro.Name, registro.ID))
+	}
+
+	// Limpiar las marcas de montaje que no corresponden a ninguna entrada restaurada
+	limpiadas, err := limpiarMarcasObsoletas(file, path, &mbr)
+	return append(messages, limpiadas...), err
+}
+
+// Limpia las marcas de montaje del disco que no corresponden a ninguna partición
+// montada en el motor: las primarias con PartStatus '1' y los EBRs con Mount '1'
+// que quedaron de una ejecución anterior del servidor. Se llama con el candado del
+// disco tomado; mbr se actualiza en memoria y en el disco. Devuelve un mensaje por
+// cada marca limpiada.
+func limpiarMarcasObsoletas(file *os.File, path string, mbr *MBR) ([]string, error) {
+	var messages []string
+	logicas := make(map[int64]bool) // EBRs de las lógicas montadas del disco
+	for _, montada := range motor.listarMontajes() {
+		if montada.Path == path && montada.Partition.PartType == 'l' {
+			logicas[montada.EBRStart] = true
+		}
+	}
+
+	mbrModificado := false
+	for i := range mbr.Partitions {
+		part := &mbr.Partitions[i]
+		if part.PartStatus != '1' {
+			continue
+		}
+		id := strings.ToLower(strings.Trim(string(part.PartId[:]), "\x00"))
+		if montada, existe := motor.montaje(id); existe && montada.Path == path && montada.Partition.PartType != 'l' {
+			continue
+		}
+		part.PartStatus = '0'
+		part.PartCorrelative = 0
+		part.PartId = [4]byte{}
+		mbrModificado = true
+		messages = append(messages, fmt.Sprintf("Marca de montaje obsoleta limpiada en la partición '%s' de %s", strings.Trim(string(part.PartName[:]), "\x00"), path))
+	}
+	if mbrModificado {
+		if err := escribirMBR(file, mbr); err != nil {
+			return messages, fmt.Errorf("error al escribir el MBR: %v", err)
+		}
+	}
+
+	ebrs := leerEBRsDelMBR(file, *mbr)
+	for i := range ebrs {
+		ebr := &ebrs[i]
+		if ebr.Mount == '1' && !logicas[ebr.Start] {
+			ebr.Mount = '0'
+			if err := writeEBR(file, ebr, ebr.Start); err != nil {
+				return messages, err
+			}
+			messages = append(messages, fmt.Sprintf("Marca de montaje obsoleta limpiada en la partición lógica '%s' de %s", strings.Trim(string(ebr.Name[:]), "\x00"), path))
+		}
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Marcas de montaje de las particiones del disco por nombre
+func marcasMontaje(t *testing.T, path string) map[string]byte {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marcas := make(map[string]byte)
+	for _, part := range mbr.Partitions {
+		if part.PartStatus != 0 {
+			marcas[strings.Trim(string(part.PartName[:]), "\x00")] = part.PartStatus
+		}
+	}
+	for _, ebr := range leerEBRsDelMBR(file, mbr) {
+		marcas[strings.Trim(string(ebr.Name[:]), "\x00")] = ebr.Mount
+	}
+	return marcas
+}
+
+// Las marcas de montaje que quedaron en un disco ausente de la tabla de montajes se
+// limpian al montar una de sus particiones, sin tocar las montadas en el motor
+func TestMountLimpiaMarcasDeDiscosFueraDeLaTabla(t *testing.T) {
+	anterior := directorioDatos
+	directorioDatos = t.TempDir()
+	t.Cleanup(func() { directorioDatos = anterior })
+
+	path := filepath.Join(t.TempDir(), "disco.mia")
+	crearDisco(path, 128*1024, 'F', 4096)
+	for _, particion := range []struct {
+		nombre, tipo string
+		tamano       int64
+	}{{"p1", "p", 8 * 1024}, {"p2", "p", 8 * 1024}, {"ext", "e", 32 * 1024}} {
+		if err := crearParticion(path, particion.tamano, particion.nombre, particion.tipo, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := crearParticionLogica(path, 8*1024, "l1", "F"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Marcas de una ejecución anterior cuyo montaje no quedó en la tabla
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr, err := leerMBR(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbr.Partitions[1].PartStatus = '1'
+	copy(mbr.Partitions[1].PartId[:], "999z")
+	if err := escribirMBR(file, &mbr); err != nil {
+		t.Fatal(err)
+	}
+	ebrs := leerEBRsDelMBR(file, mbr)
+	if len(ebrs) != 1 {
+		t.Fatalf("se esperaba una lógica, hay %d", len(ebrs))
+	}
+	ebrs[0].Mount = '1'
+	if err := writeEBR(file, &ebrs[0], ebrs[0].Start); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if err := mountPartition(path, "p1", "99", false); err != nil {
+		t.Fatal(err)
+	}
+	_, montada := isPartitionMounted(path, "p1")
+	t.Cleanup(func() { motor.quitarMontaje(montada.ID) })
+
+	marcas := marcasMontaje(t, path)
+	if marcas["p1"] != '1' || marcas["p2"] != '0' || marcas["l1"] != '0' {
+		t.Fatalf("marcas después de montar p1: p1=%c p2=%c l1=%c", marcas["p1"], marcas["p2"], marcas["l1"])
+	}
+
+	// Montar otra partición del disco no limpia la marca de p1
+	if err := mountPartition(path, "l1", "99", false); err != nil {
+		t.Fatal(err)
+	}
+	_, logica := isPartitionMounted(path, "l1")
+	t.Cleanup(func() { motor.quitarMontaje(logica.ID) })
+	marcas = marcasMontaje(t, path)
+	if marcas["p1"] != '1' || marcas["l1"] != '1' {
+		t.Fatalf("marcas después de montar l1: p1=%c l1=%c", marcas["p1"], marcas["l1"])
+	}
+}
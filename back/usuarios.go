@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Administración de grupos y usuarios en /users.txt. Cada línea es un registro:
+//
+//	GID,G,grupo
+//	UID,U,grupo,usuario,contraseña
+//
+// Los IDs se asignan en orden según la cantidad de registros del mismo tipo y un
+// registro eliminado queda con ID 0, así un ID nunca se reutiliza.
+
+// Tamaño máximo de los nombres de grupos y usuarios y de las contraseñas
+const maxCampoUsuarios = 10
+
+const rutaUsuarios = "/users.txt"
+
+// Registro de users.txt separado en sus campos
+type registroUsuarios []string
+
+func (r registroUsuarios) id() int {
+	id, _ := strconv.Atoi(r[0])
+	return id
+}
+
+func (r registroUsuarios) esGrupo() bool   { return len(r) == 3 && r[1] == "G" }
+func (r registroUsuarios) esUsuario() bool { return len(r) == 5 && r[1] == "U" }
+
+// -----------------------------------PARÁMETROS--------------------------------------
+
+func parseMkgrpCommand(command string) (string, error) {
+	parametros, err := leerParametros(command, "name")
+	if err != nil {
+		return "", err
+	}
+	return parametros["name"], validarCampoUsuarios("name", parametros["name"])
+}
+
+func parseRmgrpCommand(command string) (string, error) {
+	return parseMkgrpCommand(command)
+}
+
+func parseMkusrCommand(command string) (usuario, password, grupo string, err error) {
+	parametros, err := leerParametros(command, "user", "pass", "grp")
+	if err != nil {
+		return "", "", "", err
+	}
+	usuario, password, grupo = parametros["user"], parametros["pass"], parametros["grp"]
+	for _, campo := range []string{"user", "pass", "grp"} {
+		if err := validarCampoUsuarios(campo, parametros[campo]); err != nil {
+			return "", "", "", err
+		}
+	}
+	return usuario, password, grupo, nil
+}
+
+func parseRmusrCommand(command string) (string, error) {
+	parametros, err := leerParametros(command, "user")
+	if err != nil {
+		return "", err
+	}
+	return parametros["user"], validarCampoUsuarios("user", parametros["user"])
+}
+
+func parseChgrpCommand(command string) (usuario, grupo string, err error) {
+	parametros, err := leerParametros(command, "user", "grp")
+	if err != nil {
+		return "", "", err
+	}
+	usuario, grupo = parametros["user"], parametros["grp"]
+	for _, campo := range []string{"user", "grp"} {
+		if err := validarCampoUsuarios(campo, parametros[campo]); err != nil {
+			return "", "", err
+		}
+	}
+	return usuario, grupo, nil
+}
+
+// Verifica que el valor se pueda guardar como campo de users.txt
+func validarCampoUsuarios(parametro, valor string) error {
+	if valor == "" {
+		return fmt.Errorf("el parámetro -%s es obligatorio", parametro)
+	}
+	if len(valor) > maxCampoUsuarios {
+		return fmt.Errorf("el parámetro -%s excede %d caracteres", parametro, maxCampoUsuarios)
+	}
+	if strings.ContainsAny(valor, ",\n\"") {
+		return fmt.Errorf("el parámetro -%s no puede contener comas, comillas ni saltos de línea", parametro)
+	}
+	return nil
+}
+
+// -------------------------------------COMANDOS--------------------------------------
+
+// Comando mkgrp: crea un grupo
+func crearGrupo(nombre string) error {
+	if err := validarCampoUsuarios("name", nombre); err != nil {
+		return err
+	}
+	return modificarUsuarios("mkgrp", fmt.Sprintf("-name=\"%s\"", nombre), func(fs *sistemaArchivos) error {
+		return fs.crearGrupo(nombre)
+	})
+}
+
+// Comando rmgrp: elimina un grupo
+func eliminarGrupo(nombre string) error {
+	return modificarUsuarios("rmgrp", fmt.Sprintf("-name=\"%s\"", nombre), func(fs *sistemaArchivos) error {
+		return fs.eliminarGrupo(nombre)
+	})
+}
+
+// Comando mkusr: crea un usuario dentro de un grupo
+func crearUsuario(usuario, password, grupo string) error {
+	for campo, valor := range map[string]string{"user": usuario, "pass": password, "grp": grupo} {
+		if err := validarCampoUsuarios(campo, valor); err != nil {
+			return err
+		}
+	}
+	parametros := fmt.Sprintf("-user=\"%s\" -pass=\"%s\" -grp=\"%s\"", usuario, password, grupo)
+	return modificarUsuarios("mkusr", parametros, func(fs *sistemaArchivos) error {
+		return fs.crearUsuario(usuario, password, grupo)
+	})
+}
+
+// Comando rmusr: elimina un usuario
+func eliminarUsuario(usuario string) error {
+	return modificarUsuarios("rmusr", fmt.Sprintf("-user=\"%s\"", usuario), func(fs *sistemaArchivos) error {
+		return fs.eliminarUsuario(usuario)
+	})
+}
+
+// Comando chgrp: cambia el grupo de un usuario
+func cambiarGrupo(usuario, grupo string) error {
+	if err := validarCampoUsuarios("grp", grupo); err != nil {
+		return err
+	}
+	return modificarUsuarios("chgrp", fmt.Sprintf("-user=\"%s\" -grp=\"%s\"", usuario, grupo), func(fs *sistemaArchivos) error {
+		return fs.cambiarGrupo(usuario, grupo)
+	})
+}
+
+// Ejecuta una operación sobre users.txt, la registra en el journal y recarga los
+// usuarios de la sesión para que los cambios de grupo se apliquen de inmediato
+func modificarUsuarios(operacion, parametros string, cambiar func(fs *sistemaArchivos) error) error {
+	return modificarSistemaArchivos(func(fs *sistemaArchivos) error {
+		err := fs.conJournal(operacion, rutaUsuarios, parametros, func() error {
+			return cambiar(fs)
+		})
+		if err != nil {
+			return err
+		}
+		usuarios, err := loadUsers(fs.file, fs.superblock)
+		if err != nil {
+			return err
+		}
+		motor.actualizarUsuarios(usuarios)
+		return nil
+	})
+}
+
+// --------------------------------OPERACIONES-USERS.TXT------------------------------
+
+func (fs *sistemaArchivos) crearGrupo(nombre string) error {
+	return fs.cambiarRegistrosUsuarios(func(registros []registroUsuarios) ([]registroUsuarios, error) {
+		if buscarRegistro(registros, "G", nombre) != nil {
+			return nil, fmt.Errorf("el grupo '%s' ya existe", nombre)
+		}
+		gid := contarRegistros(registros, "G") + 1
+		return append(registros, registroUsuarios{strconv.Itoa(gid), "G", nombre}), nil
+	})
+}
+
+func (fs *sistemaArchivos) eliminarGrupo(nombre string) error {
+	return fs.cambiarRegistrosUsuarios(func(registros []registroUsuarios) ([]registroUsuarios, error) {
+		if nombre == "root" {
+			return nil, fmt.Errorf("no se puede eliminar el grupo root")
+		}
+		grupo := buscarRegistro(registros, "G", nombre)
+		if grupo == nil {
+			return nil, fmt.Errorf("el grupo '%s' no existe", nombre)
+		}
+		grupo[0] = "0"
+		return registros, nil
+	})
+}
+
+func (fs *sistemaArchivos) crearUsuario(usuario, password, grupo string) error {
+	return fs.cambiarRegistrosUsuarios(func(registros []registroUsuarios) ([]registroUsuarios, error) {
+		if buscarRegistro(registros, "U", usuario) != nil {
+			return nil, fmt.Errorf("el usuario '%s' ya existe", usuario)
+		}
+		if buscarRegistro(registros, "G", grupo) == nil {
+			return nil, fmt.Errorf("el grupo '%s' no existe", grupo)
+		}
+		uid := contarRegistros(registros, "U") + 1
+		return append(registros, registroUsuarios{strconv.Itoa(uid), "U", grupo, usuario, password}), nil
+	})
+}
+
+func (fs *sistemaArchivos) eliminarUsuario(usuario string) error {
+	return fs.cambiarRegistrosUsuarios(func(registros []registroUsuarios) ([]registroUsuarios, error) {
+		registro := buscarRegistro(registros, "U", usuario)
+		if registro == nil {
+			return nil, fmt.Errorf("el usuario '%s' no existe", usuario)
+		}
+		if registro.id() == uidRoot {
+			return nil, fmt.Errorf("no se puede eliminar el usuario root")
+		}
+		registro[0] = "0"
+		return registros, nil
+	})
+}
+
+func (fs *sistemaArchivos) cambiarGrupo(usuario, grupo string) error {
+	return fs.cambiarRegistrosUsuarios(func(registros []registroUsuarios) ([]registroUsuarios, error) {
+		registro := buscarRegistro(registros, "U", usuario)
+		if registro == nil {
+			return nil, fmt.Errorf("el usuario '%s' no existe", usuario)
+		}
+		if buscarRegistro(registros, "G", grupo) == nil {
+			return nil, fmt.Errorf("el grupo '%s' no existe", grupo)
+		}
+		registro[2] = grupo
+		return registros, nil
+	})
+}
+
+// Lee los registros de users.txt, los modifica con cambiar y reescribe el archivo.
+// Solo root puede modificar los grupos y usuarios.
+func (fs *sistemaArchivos) cambiarRegistrosUsuarios(cambiar func([]registroUsuarios) ([]registroUsuarios, error)) error {
+	if fs.sesion.ID != uidRoot {
+		return ErrSoloRoot
+	}
+	archivo, err := fs.leerInodo(inodoUsuarios)
+	if err != nil {
+		return err
+	}
+	contenido, err := fs.leerContenido(archivo)
+	if err != nil {
+		return err
+	}
+
+	var registros []registroUsuarios
+	for _, linea := range strings.Split(string(contenido), "\n") {
+		if strings.TrimSpace(linea) == "" {
+			continue
+		}
+		campos := strings.Split(linea, ",")
+		for i := range campos {
+			campos[i] = strings.TrimSpace(campos[i])
+		}
+		registros = append(registros, campos)
+	}
+	if registros, err = cambiar(registros); err != nil {
+		return err
+	}
+
+	var nuevo strings.Builder
+	for _, registro := range registros {
+		nuevo.WriteString(strings.Join(registro, ",") + "\n")
+	}
+	ocupados, err := fs.contarBloques(archivo)
+	if err != nil {
+		return err
+	}
+	if err := fs.verificarEspacio([]byte(nuevo.String()), ocupados); err != nil {
+		return err
+	}
+	if err := fs.liberarBloques(archivo); err != nil {
+		return err
+	}
+	if err := fs.escribirContenido(archivo, []byte(nuevo.String())); err != nil {
+		return err
+	}
+	return fs.escribirInodo(inodoUsuarios, archivo)
+}
+
+// Busca el registro activo (ID distinto de 0) del tipo con ese nombre: el nombre
+// del grupo para "G" y el del usuario para "U"
+func buscarRegistro(registros []registroUsuarios, tipo, nombre string) registroUsuarios {
+	for _, registro := range registros {
+		if registro.id() == 0 {
+			continue
+		}
+		if tipo == "G" && registro.esGrupo() && registro[2] == nombre {
+			return registro
+		}
+		if tipo == "U" && registro.esUsuario() && registro[3] == nombre {
+			return registro
+		}
+	}
+	return nil
+}
+
+// Cantidad de registros del tipo, incluidos los eliminados
+func contarRegistros(registros []registroUsuarios, tipo string) int {
+	total := 0
+	for _, registro := range registros {
+		if (tipo == "G" && registro.esGrupo()) || (tipo == "U" && registro.esUsuario()) {
+			total++
+		}
+	}
+	return total
+}